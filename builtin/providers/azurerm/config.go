@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/Godeps/_workspace/src/github.com/Azure/go-autorest/autorest"
@@ -15,6 +17,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/arm/scheduler"
 	"github.com/Azure/azure-sdk-for-go/arm/storage"
 	mainStorage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 	riviera "github.com/jen20/riviera/azure"
 )
@@ -60,6 +63,162 @@ type ArmClient struct {
 	storageUsageClient   storage.UsageOperationsClient
 
 	deploymentsClient resources.DeploymentsClient
+
+	// blobWriteLimiter bounds the number of concurrent blob write operations
+	// made against each storage account.
+	blobWriteLimiter *storageAccountLimiter
+
+	// blobClients caches a blob storage client per storage account, so that
+	// the account key is only looked up once per run.
+	blobClients *storageBlobClientCache
+
+	// storageEndpointSuffix is the domain suffix used to build the endpoints of
+	// the storage data plane, e.g. core.windows.net in the public cloud.
+	storageEndpointSuffix string
+
+	// blobProgressThreshold is the number of bytes between the progress lines
+	// logged while uploading a blob, or 0 to log no progress.
+	blobProgressThreshold int64
+
+	// blobParallelism is the number of blocks uploaded at once for blobs
+	// which don't set their own parallelism.
+	blobParallelism int
+
+	// blobRetries is the number of times a blob request which fails with a
+	// transient error is retried, by the kind of operation it is part of.
+	blobRetries map[armStorageBlobOperation]int
+}
+
+// storageAccountLimiter is a set of semaphores, one per storage account, which
+// bound the number of concurrent operations made against each account. Each
+// storage account has its own throttling budget on the Azure side, so a slow
+// or throttled account must not hold up operations against other accounts.
+type storageAccountLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newStorageAccountLimiter(limit int) *storageAccountLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &storageAccountLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// acquire blocks until a slot is free for the given storage account, and
+// returns a function which releases it.
+func (l *storageAccountLimiter) acquire(storageAccountName string) func() {
+	l.mu.Lock()
+	slots, ok := l.slots[storageAccountName]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[storageAccountName] = slots
+	}
+	l.mu.Unlock()
+
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// storageBlobClientCache holds the blob storage clients already built for
+// storage accounts, keyed by resource group and account name. The account key
+// each client was built with is kept next to it, so that anything else signed
+// with the key uses the same one, and is dropped with the client.
+type storageBlobClientCache struct {
+	mu      sync.Mutex
+	clients map[string]storageBlobClientCacheEntry
+}
+
+type storageBlobClientCacheEntry struct {
+	client *mainStorage.BlobStorageClient
+	key    string
+}
+
+func newStorageBlobClientCache() *storageBlobClientCache {
+	return &storageBlobClientCache{
+		clients: make(map[string]storageBlobClientCacheEntry),
+	}
+}
+
+func storageBlobClientCacheKey(resourceGroupName, storageAccountName string) string {
+	return resourceGroupName + "/" + storageAccountName
+}
+
+// get returns the cached client for the storage account and the key it was
+// built with, if any. A nil cache holds nothing.
+func (c *storageBlobClientCache) get(resourceGroupName, storageAccountName string) (*mainStorage.BlobStorageClient, string, bool) {
+	if c == nil {
+		return nil, "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.clients[storageBlobClientCacheKey(resourceGroupName, storageAccountName)]
+	return entry.client, entry.key, ok
+}
+
+func (c *storageBlobClientCache) put(resourceGroupName, storageAccountName string, client *mainStorage.BlobStorageClient, key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clients[storageBlobClientCacheKey(resourceGroupName, storageAccountName)] = storageBlobClientCacheEntry{
+		client: client,
+		key:    key,
+	}
+}
+
+// invalidate drops the cached client and key for the storage account, so that
+// the next client is built with a freshly looked up key.
+func (c *storageBlobClientCache) invalidate(resourceGroupName, storageAccountName string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.clients, storageBlobClientCacheKey(resourceGroupName, storageAccountName))
+}
+
+// isArmStorageAuthFailure reports whether err shows that the storage service
+// rejected a request's credentials, as it does once the account key has been
+// rotated. Resource functions wrap the storage SDK's errors with their own
+// context, so the text of the error is checked as well as its type.
+func isArmStorageAuthFailure(err error) bool {
+	switch e := err.(type) {
+	case mainStorage.AzureStorageServiceError:
+		return e.StatusCode == http.StatusForbidden
+	case mainStorage.UnexpectedStatusCodeError:
+		return e.Got() == http.StatusForbidden
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "AuthenticationFailed") || strings.Contains(msg, "403 Forbidden")
+}
+
+// invalidatingStorageBlobClient wraps a function of a resource in a storage
+// account. When it fails because the account key was rejected, the account's
+// cached blob client is dropped, so that the next operation looks up the
+// current key.
+func invalidatingStorageBlobClient(f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		err := f(d, meta)
+		if err != nil && isArmStorageAuthFailure(err) {
+			resourceGroupName := d.Get("resource_group_name").(string)
+			storageAccountName := d.Get("storage_account_name").(string)
+			log.Printf("[INFO] Dropping the cached blob client of storage account %q after an authentication failure", storageAccountName)
+			meta.(*ArmClient).blobClients.invalidate(resourceGroupName, storageAccountName)
+		}
+		return err
+	}
 }
 
 func withRequestLogging() autorest.SendDecorator {
@@ -108,7 +267,18 @@ func setUserAgent(client *autorest.Client) {
 // *ArmClient based on the Config's current settings.
 func (c *Config) getArmClient() (*ArmClient, error) {
 	// client declarations:
-	client := ArmClient{}
+	client := ArmClient{
+		blobWriteLimiter:      newStorageAccountLimiter(c.StorageAccountConcurrency),
+		blobClients:           newStorageBlobClientCache(),
+		storageEndpointSuffix: c.StorageEndpointSuffix,
+		blobProgressThreshold: int64(c.StorageBlobProgressThreshold),
+		blobParallelism:       c.StorageBlobParallelism,
+		blobRetries: map[armStorageBlobOperation]int{
+			armStorageBlobWrite:  c.StorageBlobWriteRetries,
+			armStorageBlobRead:   c.StorageBlobReadRetries,
+			armStorageBlobDelete: c.StorageBlobDeleteRetries,
+		},
+	}
 
 	rivieraClient, err := riviera.NewClient(&riviera.AzureResourceManagerCredentials{
 		ClientID:       c.ClientID,
@@ -327,19 +497,48 @@ func (armClient *ArmClient) getKeyForStorageAccount(resourceGroupName, storageAc
 	return *keys.Key1, nil
 }
 
+// newStorageClient returns a storage data plane client for the given account,
+// using the configured storage endpoint suffix.
+func (armClient *ArmClient) newStorageClient(storageAccountName, key string) (mainStorage.Client, error) {
+	suffix := armClient.storageEndpointSuffix
+	if suffix == "" {
+		suffix = mainStorage.DefaultBaseURL
+	}
+
+	storageClient, err := mainStorage.NewClient(storageAccountName, key, suffix, mainStorage.DefaultAPIVersion, true)
+	if err != nil {
+		return storageClient, fmt.Errorf("Error creating storage client for storage account %q: %s", storageAccountName, err)
+	}
+
+	return storageClient, nil
+}
+
 func (armClient *ArmClient) getBlobStorageClientForStorageAccount(resourceGroupName, storageAccountName string) (*mainStorage.BlobStorageClient, error) {
+	blobClient, _, err := armClient.getBlobStorageClientAndKeyForStorageAccount(resourceGroupName, storageAccountName)
+	return blobClient, err
+}
+
+// getBlobStorageClientAndKeyForStorageAccount returns the blob client of a
+// storage account along with the account key it was built with, both from the
+// cache when they are there.
+func (armClient *ArmClient) getBlobStorageClientAndKeyForStorageAccount(resourceGroupName, storageAccountName string) (*mainStorage.BlobStorageClient, string, error) {
+	if blobClient, key, ok := armClient.blobClients.get(resourceGroupName, storageAccountName); ok {
+		return blobClient, key, nil
+	}
+
 	key, err := armClient.getKeyForStorageAccount(resourceGroupName, storageAccountName)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	storageClient, err := mainStorage.NewBasicClient(storageAccountName, key)
+	storageClient, err := armClient.newStorageClient(storageAccountName, key)
 	if err != nil {
-		return nil, fmt.Errorf("Error creating storage client for storage account %q: %s", storageAccountName, err)
+		return nil, "", err
 	}
 
 	blobClient := storageClient.GetBlobService()
-	return &blobClient, nil
+	armClient.blobClients.put(resourceGroupName, storageAccountName, &blobClient, key)
+	return &blobClient, key, nil
 }
 func (armClient *ArmClient) getQueueServiceClientForStorageAccount(resourceGroupName, storageAccountName string) (*mainStorage.QueueServiceClient, error) {
 	key, err := armClient.getKeyForStorageAccount(resourceGroupName, storageAccountName)
@@ -347,9 +546,9 @@ func (armClient *ArmClient) getQueueServiceClientForStorageAccount(resourceGroup
 		return nil, err
 	}
 
-	storageClient, err := mainStorage.NewBasicClient(storageAccountName, key)
+	storageClient, err := armClient.newStorageClient(storageAccountName, key)
 	if err != nil {
-		return nil, fmt.Errorf("Error creating storage client for storage account %q: %s", storageAccountName, err)
+		return nil, err
 	}
 
 	queueClient := storageClient.GetQueueService()
@@ -0,0 +1,187 @@
+package azurerm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestStorageAccountLimiter_perAccount(t *testing.T) {
+	limiter := newStorageAccountLimiter(2)
+
+	var mu sync.Mutex
+	active := make(map[string]int)
+	peak := make(map[string]int)
+
+	var wg sync.WaitGroup
+	for _, account := range []string{"one", "two", "three"} {
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func(account string) {
+				defer wg.Done()
+
+				release := limiter.acquire(account)
+				defer release()
+
+				mu.Lock()
+				active[account]++
+				if active[account] > peak[account] {
+					peak[account] = active[account]
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				active[account]--
+				mu.Unlock()
+			}(account)
+		}
+	}
+	wg.Wait()
+
+	for account, p := range peak {
+		if p > 2 {
+			t.Fatalf("Expected at most 2 concurrent operations against %q, got %d", account, p)
+		}
+	}
+}
+
+func TestStorageAccountLimiter_accountsIndependent(t *testing.T) {
+	limiter := newStorageAccountLimiter(1)
+
+	// hold the only slot for the first account
+	release := limiter.acquire("slow")
+	defer release()
+
+	done := make(chan struct{})
+	go func() {
+		r := limiter.acquire("fast")
+		r()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected an operation against a different account not to be blocked")
+	}
+}
+
+func TestArmClientNewStorageClient_endpointSuffix(t *testing.T) {
+	cases := []struct {
+		Suffix   string
+		Expected string
+	}{
+		{
+			Suffix:   "",
+			Expected: "https://acctestacc.blob.core.windows.net/vhds/example.vhd",
+		},
+		{
+			Suffix:   "core.usgovcloudapi.net",
+			Expected: "https://acctestacc.blob.core.usgovcloudapi.net/vhds/example.vhd",
+		},
+		{
+			Suffix:   "core.chinacloudapi.cn",
+			Expected: "https://acctestacc.blob.core.chinacloudapi.cn/vhds/example.vhd",
+		},
+	}
+
+	for _, tc := range cases {
+		armClient := &ArmClient{storageEndpointSuffix: tc.Suffix}
+		storageClient, err := armClient.newStorageClient("acctestacc", "dGVzdGtleQ==")
+		if err != nil {
+			t.Fatalf("Error creating storage client: %s", err)
+		}
+
+		blobClient := storageClient.GetBlobService()
+		if url := blobClient.GetBlobURL("vhds", "example.vhd"); url != tc.Expected {
+			t.Fatalf("Expected blob URL %q for suffix %q, got %q", tc.Expected, tc.Suffix, url)
+		}
+	}
+}
+
+func TestStorageBlobClientCache(t *testing.T) {
+	storageClient, err := (&ArmClient{}).newStorageClient("acctestacc", "dGVzdGtleQ==")
+	if err != nil {
+		t.Fatalf("Error creating storage client: %s", err)
+	}
+	blobClient := storageClient.GetBlobService()
+
+	armClient := &ArmClient{blobClients: newStorageBlobClientCache()}
+	armClient.blobClients.put("acctestrg", "acctestacc", &blobClient, "dGVzdGtleQ==")
+
+	// A cached client is returned without looking up the account key
+	cached, err := armClient.getBlobStorageClientForStorageAccount("acctestrg", "acctestacc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if cached != &blobClient {
+		t.Fatalf("Expected the cached blob client to be returned")
+	}
+
+	// The key the client was built with is cached with it
+	cached, key, err := armClient.getBlobStorageClientAndKeyForStorageAccount("acctestrg", "acctestacc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if cached != &blobClient || key != "dGVzdGtleQ==" {
+		t.Fatalf("Expected the cached blob client and key to be returned, got key %q", key)
+	}
+
+	// Accounts of the same name in other resource groups are distinct
+	if _, _, ok := armClient.blobClients.get("otherrg", "acctestacc"); ok {
+		t.Fatalf("Expected no cached client for another resource group")
+	}
+
+	armClient.blobClients.invalidate("acctestrg", "acctestacc")
+	if _, key, ok := armClient.blobClients.get("acctestrg", "acctestacc"); ok || key != "" {
+		t.Fatalf("Expected the invalidated client and key to be dropped")
+	}
+
+	// A nil cache holds nothing
+	var nilCache *storageBlobClientCache
+	nilCache.put("acctestrg", "acctestacc", &blobClient, "dGVzdGtleQ==")
+	if _, _, ok := nilCache.get("acctestrg", "acctestacc"); ok {
+		t.Fatalf("Expected a nil cache to hold nothing")
+	}
+}
+
+func TestStorageBlobClientCache_invalidateOnAuthFailure(t *testing.T) {
+	cases := []struct {
+		Err        error
+		Invalidate bool
+	}{
+		{Err: nil, Invalidate: false},
+		{Err: storage.AzureStorageServiceError{StatusCode: 403, Code: "AuthenticationFailed"}, Invalidate: true},
+		{Err: fmt.Errorf("Error retrieving properties of storage blob %q: %s", "example.vhd", storage.AzureStorageServiceError{StatusCode: 403, Code: "AuthenticationFailed"}), Invalidate: true},
+		{Err: storage.AzureStorageServiceError{StatusCode: 404, Code: "BlobNotFound"}, Invalidate: false},
+		{Err: errors.New("Error uploading storage blob: connection reset"), Invalidate: false},
+	}
+
+	for i, tc := range cases {
+		armClient := &ArmClient{blobClients: newStorageBlobClientCache()}
+		armClient.blobClients.put("acctestrg", "acctestacc", &storage.BlobStorageClient{}, "dGVzdGtleQ==")
+
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("resource_group_name", "acctestrg")
+		d.Set("storage_account_name", "acctestacc")
+
+		f := invalidatingStorageBlobClient(func(*schema.ResourceData, interface{}) error {
+			return tc.Err
+		})
+		if err := f(d, armClient); err != tc.Err {
+			t.Fatalf("%d: expected the error to be returned unchanged, got %v", i, err)
+		}
+
+		_, _, ok := armClient.blobClients.get("acctestrg", "acctestacc")
+		if ok == tc.Invalidate {
+			t.Fatalf("%d: expected invalidated %t, got %t", i, tc.Invalidate, !ok)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/Godeps/_workspace/src/github.com/Azure/go-autorest/autorest"
+	mainStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/mutexkv"
 	"github.com/hashicorp/terraform/helper/resource"
@@ -44,6 +45,48 @@ func Provider() terraform.ResourceProvider {
 				Required:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ARM_TENANT_ID", ""),
 			},
+
+			"storage_account_concurrency": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_ACCOUNT_CONCURRENCY", 8),
+			},
+
+			"storage_endpoint_suffix": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_ENDPOINT_SUFFIX", mainStorage.DefaultBaseURL),
+			},
+
+			"storage_blob_progress_threshold": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_BLOB_PROGRESS_THRESHOLD", 64*1024*1024),
+			},
+
+			"storage_blob_parallelism": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_BLOB_PARALLELISM", 8),
+			},
+
+			"storage_blob_write_retries": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_BLOB_WRITE_RETRIES", 3),
+			},
+
+			"storage_blob_read_retries": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_BLOB_READ_RETRIES", 3),
+			},
+
+			"storage_blob_delete_retries": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_BLOB_DELETE_RETRIES", 1),
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -93,6 +136,31 @@ type Config struct {
 	ClientSecret   string
 	TenantID       string
 
+	// StorageAccountConcurrency is the maximum number of concurrent blob
+	// write operations made against any one storage account.
+	StorageAccountConcurrency int
+
+	// StorageEndpointSuffix is the domain suffix of the storage service
+	// endpoints, which differs from the public cloud in sovereign clouds.
+	StorageEndpointSuffix string
+
+	// StorageBlobProgressThreshold is the number of bytes between the progress
+	// lines logged while uploading a blob. Blobs smaller than it log no
+	// progress, and 0 turns progress logging off.
+	StorageBlobProgressThreshold int
+
+	// StorageBlobParallelism is the number of blocks uploaded at once for
+	// blobs which don't set their own parallelism.
+	StorageBlobParallelism int
+
+	// StorageBlobWriteRetries, StorageBlobReadRetries and
+	// StorageBlobDeleteRetries are the number of times a blob request which
+	// fails with a transient error is retried, for uploads, reads and
+	// deletes respectively.
+	StorageBlobWriteRetries  int
+	StorageBlobReadRetries   int
+	StorageBlobDeleteRetries int
+
 	validateCredentialsOnce sync.Once
 }
 
@@ -111,6 +179,21 @@ func (c *Config) validate() error {
 	if c.TenantID == "" {
 		err = multierror.Append(err, fmt.Errorf("Tenant ID must be configured for the AzureRM provider"))
 	}
+	if c.StorageEndpointSuffix == "" {
+		err = multierror.Append(err, fmt.Errorf("Storage Endpoint Suffix must not be empty for the AzureRM provider"))
+	}
+	if c.StorageAccountConcurrency < 1 {
+		err = multierror.Append(err, fmt.Errorf("Storage Account Concurrency must be at least 1 for the AzureRM provider"))
+	}
+	if c.StorageBlobProgressThreshold < 0 {
+		err = multierror.Append(err, fmt.Errorf("Storage Blob Progress Threshold must not be negative for the AzureRM provider"))
+	}
+	if c.StorageBlobParallelism < 1 || c.StorageBlobParallelism > armStorageBlobMaxParallelism {
+		err = multierror.Append(err, fmt.Errorf("Storage Blob Parallelism must be between 1 and %d for the AzureRM provider", armStorageBlobMaxParallelism))
+	}
+	if c.StorageBlobWriteRetries < 0 || c.StorageBlobReadRetries < 0 || c.StorageBlobDeleteRetries < 0 {
+		err = multierror.Append(err, fmt.Errorf("Storage Blob Write, Read and Delete Retries must not be negative for the AzureRM provider"))
+	}
 
 	return err.ErrorOrNil()
 }
@@ -121,6 +204,15 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		ClientID:       d.Get("client_id").(string),
 		ClientSecret:   d.Get("client_secret").(string),
 		TenantID:       d.Get("tenant_id").(string),
+
+		StorageAccountConcurrency: d.Get("storage_account_concurrency").(int),
+		StorageEndpointSuffix:     d.Get("storage_endpoint_suffix").(string),
+
+		StorageBlobProgressThreshold: d.Get("storage_blob_progress_threshold").(int),
+		StorageBlobParallelism:       d.Get("storage_blob_parallelism").(int),
+		StorageBlobWriteRetries:      d.Get("storage_blob_write_retries").(int),
+		StorageBlobReadRetries:       d.Get("storage_blob_read_retries").(int),
+		StorageBlobDeleteRetries:     d.Get("storage_blob_delete_retries").(int),
 	}
 
 	if err := config.validate(); err != nil {
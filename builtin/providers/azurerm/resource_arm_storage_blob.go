@@ -1,25 +1,49 @@
 package azurerm
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	armStorage "github.com/Azure/azure-sdk-for-go/arm/storage"
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
 func resourceArmStorageBlob() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceArmStorageBlobCreate,
-		Read:   resourceArmStorageBlobRead,
+		Create: invalidatingStorageBlobClient(resourceArmStorageBlobCreate),
+		Read:   invalidatingStorageBlobClient(resourceArmStorageBlobRead),
+		Update: invalidatingStorageBlobClient(resourceArmStorageBlobUpdate),
 		Exists: resourceArmStorageBlobExists,
-		Delete: resourceArmStorageBlobDelete,
+		Delete: invalidatingStorageBlobClient(resourceArmStorageBlobDelete),
 
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateArmStorageBlobName,
 			},
 			"resource_group_name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -32,9 +56,10 @@ func resourceArmStorageBlob() *schema.Resource {
 				ForceNew: true,
 			},
 			"storage_container_name": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateArmStorageBlobContainerName,
 			},
 			"type": &schema.Schema{
 				Type:         schema.TypeString,
@@ -49,22 +74,1816 @@ func resourceArmStorageBlob() *schema.Resource {
 				Default:      0,
 				ValidateFunc: validateArmStorageBlobSize,
 			},
+			"sequence_number": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateArmStorageBlobSequenceNumber,
+			},
+			"sequence_number_action": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobSequenceNumberAction,
+			},
+			"custom_headers": &schema.Schema{
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobCustomHeaders,
+			},
+			"content_base64": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobContentBase64,
+			},
+			"source": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"content_base64"},
+			},
+			"source_uri": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ValidateFunc:  validateArmStorageBlobSourceURI,
+				ConflictsWith: []string{"source", "content_base64", "size", "empty"},
+			},
+			"decompress": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"use_mmap": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"expected_md5": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validateArmStorageBlobExpectedMD5,
+				ConflictsWith: []string{"source_uri"},
+			},
+			"empty": &schema.Schema{
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ForceNew:      true,
+				Default:       false,
+				ConflictsWith: []string{"content_base64", "size", "source"},
+			},
+			"verify_on_read": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"upload_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobUploadTimeout,
+			},
+			"copy_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobCopyDuration,
+			},
+			"copy_poll_interval": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobCopyDuration,
+			},
+			"wait_for_copy": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"validate_blocks": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobMaxRetries,
+			},
+			"parallelism": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobParallelism,
+			},
+			"block_size_bytes": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobBlockSize,
+			},
+			"verify_source": &schema.Schema{
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"write_once"},
+			},
+			"write_once": &schema.Schema{
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ForceNew:      true,
+				Default:       false,
+				ConflictsWith: []string{"verify_on_read"},
+			},
+			"atomic_replace": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"prevent_destroy_if_recently_modified": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobRecentlyModifiedWindow,
+			},
+			"force_destroy": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"delete_snapshots": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "include",
+				ValidateFunc: validateArmStorageBlobDeleteSnapshots,
+			},
+			"sas": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"policy_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"expiry": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateArmStorageBlobSASExpiry,
+						},
+						"permissions": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateArmStorageBlobSASPermissions,
+						},
+					},
+				},
+			},
 			"url": &schema.Schema{
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"sas_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_md5": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"cache_control": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateArmStorageBlobCacheControl,
+			},
+			"content_disposition": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"metadata": &schema.Schema{
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobMetadata,
+			},
+			"is_public": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"copy_source": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"copy_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"copy_status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"copy_completion_time": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"etag": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
-func validateArmStorageBlobSize(v interface{}, k string) (ws []string, errors []error) {
-	value := v.(int)
+func validateArmStorageBlobName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if utf8.RuneCountInString(value) > 1024 {
+		errors = append(errors, fmt.Errorf("%q cannot be longer than 1024 characters", k))
+	}
+
+	if value == "" {
+		errors = append(errors, fmt.Errorf("%q must be at least 1 character", k))
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			errors = append(errors, fmt.Errorf("%q cannot contain control characters", k))
+			break
+		}
+	}
+
+	return
+}
+
+// validateArmStorageBlobContainerName checks a container name against Azure's
+// naming rules. The root container is addressed as $root.
+func validateArmStorageBlobContainerName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if value == "$root" {
+		return
+	}
+
+	if !regexp.MustCompile(`^[a-z0-9-]+$`).MatchString(value) {
+		errors = append(errors, fmt.Errorf(
+			"only lowercase alphanumeric characters and hyphens allowed in %q", k))
+	}
+
+	if regexp.MustCompile(`^-`).MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q cannot start with a hyphen", k))
+	}
+
+	if regexp.MustCompile(`-$`).MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q cannot end with a hyphen", k))
+	}
+
+	if strings.Contains(value, "--") {
+		errors = append(errors, fmt.Errorf("%q cannot contain consecutive hyphens", k))
+	}
+
+	if len(value) > 63 {
+		errors = append(errors, fmt.Errorf(
+			"%q cannot be longer than 63 characters", k))
+	}
+
+	if len(value) < 3 {
+		errors = append(errors, fmt.Errorf(
+			"%q must be at least 3 characters", k))
+	}
+
+	return
+}
+
+func validateArmStorageBlobSize(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < 0 {
+		errors = append(errors, fmt.Errorf("Blob Size %d is invalid, must not be negative", value))
+	} else if value%512 != 0 {
+		errors = append(errors, fmt.Errorf("Blob Size %d is invalid, must be a multiple of 512", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobUploadTimeout(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("Upload timeout %q is invalid: %s", value, err))
+	} else if timeout <= 0 {
+		errors = append(errors, fmt.Errorf("Upload timeout %q is invalid, must be positive", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobCopyDuration(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%s %q is invalid: %s", k, value, err))
+	} else if duration <= 0 {
+		errors = append(errors, fmt.Errorf("%s %q is invalid, must be positive", k, value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobRecentlyModifiedWindow(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("Recently modified window %q is invalid: %s", value, err))
+	} else if window <= 0 {
+		errors = append(errors, fmt.Errorf("Recently modified window %q is invalid, must be positive", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobDeleteSnapshots(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	switch value {
+	case "none", "include", "only":
+	default:
+		errors = append(errors, fmt.Errorf("Delete snapshots %q is invalid, must be %q, %q or %q", value, "none", "include", "only"))
+	}
+
+	return
+}
+
+func validateArmStorageBlobSourceURI(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	u, err := url.Parse(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("Source URI %q is invalid: %s", value, err))
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errors = append(errors, fmt.Errorf("Source URI %q is invalid, must be an absolute http or https URL", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobSASExpiry(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		if duration, err := time.ParseDuration(value); err != nil || duration <= 0 {
+			errors = append(errors, fmt.Errorf("SAS expiry %q is invalid, must be an RFC 3339 time such as 2017-01-01T00:00:00Z, or a positive duration such as 24h", value))
+		}
+	}
+
+	return
+}
+
+// armStorageBlobSASPermissionsPattern matches the permissions a blob SAS can
+// grant, which Azure requires in this order.
+var armStorageBlobSASPermissionsPattern = regexp.MustCompile(`^r?w?d?$`)
+
+func validateArmStorageBlobSASPermissions(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if value == "" || !armStorageBlobSASPermissionsPattern.MatchString(value) {
+		errors = append(errors, fmt.Errorf("SAS permissions %q are invalid, must be some of \"rwd\" in that order", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobMaxRetries(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < 0 {
+		errors = append(errors, fmt.Errorf("Blob max retries %d is invalid, must not be negative", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobParallelism(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < 1 || value > armStorageBlobMaxParallelism {
+		errors = append(errors, fmt.Errorf("Blob parallelism %d is invalid, must be between 1 and %d", value, armStorageBlobMaxParallelism))
+	}
+
+	return
+}
+
+func validateArmStorageBlobBlockSize(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < armStorageBlobMinBlockSize || value > armStorageBlobBlockSize {
+		errors = append(errors, fmt.Errorf("Block size %d is invalid, must be between %d and %d bytes", value, armStorageBlobMinBlockSize, armStorageBlobBlockSize))
+	}
+
+	return
+}
+
+func validateArmStorageBlobSequenceNumber(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < 0 {
+		errors = append(errors, fmt.Errorf("Blob sequence number %d is invalid, must not be negative", value))
+	}
+
+	return
+}
+
+func validateArmStorageBlobSequenceNumberAction(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	switch value {
+	case "update", "max", "increment":
+	default:
+		errors = append(errors, fmt.Errorf("Sequence number action %q is invalid, must be %q, %q or %q", value, "update", "max", "increment"))
+	}
+
+	return
+}
+
+// armStorageBlobCustomHeaders maps the HTTP headers which Azure allows to be
+// stored on a blob to the request headers used to set them on creation.
+var armStorageBlobCustomHeaders = map[string]string{
+	"cache-control":       "x-ms-blob-cache-control",
+	"content-disposition": "x-ms-blob-content-disposition",
+	"content-encoding":    "x-ms-blob-content-encoding",
+	"content-language":    "x-ms-blob-content-language",
+	"content-type":        "x-ms-blob-content-type",
+}
+
+func validateArmStorageBlobCustomHeaders(v interface{}, k string) (ws []string, errors []error) {
+	headers := v.(map[string]interface{})
+
+	for header := range headers {
+		if _, ok := armStorageBlobCustomHeaders[strings.ToLower(header)]; !ok {
+			errors = append(errors, fmt.Errorf("Blob custom header %q is invalid, must be one of Cache-Control, Content-Disposition, Content-Encoding, Content-Language or Content-Type", header))
+		}
+	}
+
+	return
+}
+
+// armStorageBlobHeaderArguments maps the arguments which each set one of the
+// blob's HTTP headers to that header in armStorageBlobCustomHeaders.
+var armStorageBlobHeaderArguments = map[string]string{
+	"cache_control":       "cache-control",
+	"content_disposition": "content-disposition",
+	"content_type":        "content-type",
+}
+
+// expandArmStorageBlobCustomHeaders converts the custom_headers map,
+// content_type, cache_control and content_disposition into the x-ms-blob-*
+// request headers which store them on the blob.
+func expandArmStorageBlobCustomHeaders(d *schema.ResourceData) map[string]string {
+	headers := make(map[string]string)
+
+	for k, v := range d.Get("custom_headers").(map[string]interface{}) {
+		if header, ok := armStorageBlobCustomHeaders[strings.ToLower(k)]; ok {
+			headers[header] = v.(string)
+		}
+	}
+
+	for arg, header := range armStorageBlobHeaderArguments {
+		if v := d.Get(arg).(string); v != "" {
+			headers[armStorageBlobCustomHeaders[header]] = v
+		}
+	}
+
+	return headers
+}
+
+// validateArmStorageBlobHeaders checks that content_type, cache_control and
+// content_disposition agree with the custom_headers which set the same
+// properties.
+func validateArmStorageBlobHeaders(d *schema.ResourceData) error {
+	customHeaders := d.Get("custom_headers").(map[string]interface{})
+
+	for arg, header := range armStorageBlobHeaderArguments {
+		value := d.Get(arg).(string)
+		if value == "" {
+			continue
+		}
+
+		for k, v := range customHeaders {
+			if strings.ToLower(k) == header && v.(string) != value {
+				return fmt.Errorf("%s %q conflicts with the %s custom header %q", arg, value, k, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// armStorageBlobCacheControlPattern matches a comma separated list of
+// Cache-Control directives, each a token optionally followed by a token or
+// quoted string value.
+var armStorageBlobCacheControlPattern = regexp.MustCompile(
+	`^\s*[A-Za-z][A-Za-z0-9-]*(=([^\s",=]+|"[^"]*"))?(\s*,\s*[A-Za-z][A-Za-z0-9-]*(=([^\s",=]+|"[^"]*"))?)*\s*$`)
+
+func validateArmStorageBlobCacheControl(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if strings.TrimSpace(value) == "" {
+		errors = append(errors, fmt.Errorf("Cache-Control %q is invalid, must not be empty", value))
+	} else if !armStorageBlobCacheControlPattern.MatchString(value) {
+		errors = append(errors, fmt.Errorf("Cache-Control %q is invalid, must be a comma separated list of directives such as \"public, max-age=3600\"", value))
+	}
+
+	return
+}
+
+// armStorageBlobMetadataKeyPattern matches the metadata keys Azure accepts,
+// which must be valid C# identifiers.
+var armStorageBlobMetadataKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateArmStorageBlobMetadata(v interface{}, k string) (ws []string, errors []error) {
+	metadata := v.(map[string]interface{})
+
+	for key := range metadata {
+		if !armStorageBlobMetadataKeyPattern.MatchString(key) {
+			errors = append(errors, fmt.Errorf("Blob metadata key %q is invalid, must start with a letter or underscore and contain only letters, digits and underscores", key))
+		}
+	}
+
+	return
+}
+
+func expandArmStorageBlobMetadata(d *schema.ResourceData) map[string]string {
+	metadata := make(map[string]string)
+
+	for k, v := range d.Get("metadata").(map[string]interface{}) {
+		metadata[k] = v.(string)
+	}
+
+	return metadata
+}
+
+// flattenArmStorageBlobMetadata converts the metadata read from a blob, whose
+// keys Azure returns in lower case, into a map using the case of the
+// configured keys, so that keys which only differ in case are not a change.
+func flattenArmStorageBlobMetadata(metadata map[string]string, configured map[string]interface{}) map[string]interface{} {
+	keys := make(map[string]string)
+	for k := range configured {
+		keys[strings.ToLower(k)] = k
+	}
+
+	out := make(map[string]interface{})
+	for k, v := range metadata {
+		if key, ok := keys[strings.ToLower(k)]; ok {
+			k = key
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+// armStorageBlobHTTPClient makes the requests to the storage service which
+// don't go through the vendored storage SDK. Unlike http.DefaultClient it has
+// a timeout, so an unresponsive endpoint can't hang a plan or an apply.
+var armStorageBlobHTTPClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// doArmStorageBlobSASRequest makes a PUT request to a blob for an operation
+// the vendored storage SDK doesn't provide. The request is authorized with a
+// short lived, write only shared access signature, and query is appended to
+// it to select the operation.
+func doArmStorageBlobSASRequest(blobClient *storage.BlobStorageClient, container, name, query string, headers map[string]string) (*http.Response, error) {
+	uri, err := blobClient.GetBlobSASURI(container, name, time.Now().Add(15*time.Minute), "w")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", uri+query, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("x-ms-version", storage.DefaultAPIVersion)
+
+	return armStorageBlobHTTPClient.Do(req)
+}
+
+// getArmStorageBlobHTTPHeaders returns the HTTP headers of a Get Blob
+// Properties request, which include properties the vendored storage SDK
+// doesn't parse. The request is authorized with a short lived, read only
+// shared access signature.
+func getArmStorageBlobHTTPHeaders(blobClient *storage.BlobStorageClient, container, name string) (http.Header, error) {
+	uri, err := blobClient.GetBlobSASURI(container, name, time.Now().Add(15*time.Minute), "r")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("HEAD", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", storage.DefaultAPIVersion)
+
+	resp, err := armStorageBlobHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %q getting properties", resp.Status)
+	}
+	return resp.Header, nil
+}
+
+// setArmStorageBlobProperties replaces the HTTP properties stored on a blob.
+// The vendored storage SDK has no Set Blob Properties operation, so the
+// request is made with a shared access signature. Properties missing from the
+// request are cleared, so unless headers sets a new one, the Content-MD5 of
+// the blob is sent again to keep it.
+func setArmStorageBlobProperties(blobClient *storage.BlobStorageClient, container, name string, headers map[string]string) error {
+	props, err := blobClient.GetBlobProperties(container, name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := headers["x-ms-blob-content-md5"]; !ok && props.ContentMD5 != "" {
+		withMD5 := map[string]string{"x-ms-blob-content-md5": props.ContentMD5}
+		for k, v := range headers {
+			withMD5[k] = v
+		}
+		headers = withMD5
+	}
+
+	resp, err := doArmStorageBlobSASRequest(blobClient, container, name, "&comp=properties", headers)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q setting properties", resp.Status)
+	}
+	return nil
+}
+
+// expandArmStorageBlobSequenceNumberHeaders returns the headers of a Set Blob
+// Properties request which applies action to the sequence number of a page
+// blob, "update" if none is set. Azure increments the sequence number itself,
+// so none is sent with the increment action.
+func expandArmStorageBlobSequenceNumberHeaders(action string, sequenceNumber int) map[string]string {
+	if action == "" {
+		action = "update"
+	}
+
+	headers := map[string]string{"x-ms-sequence-number-action": action}
+	if action != "increment" {
+		headers["x-ms-blob-sequence-number"] = strconv.Itoa(sequenceNumber)
+	}
+	return headers
+}
+
+// setArmStorageBlobSequenceNumber changes the sequence number of a page blob
+// in place. Unlike setArmStorageBlobProperties, no x-ms-blob-content-*
+// header is sent, so Azure leaves the other properties of the blob alone.
+func setArmStorageBlobSequenceNumber(blobClient *storage.BlobStorageClient, container, name, action string, sequenceNumber int) error {
+	headers := expandArmStorageBlobSequenceNumberHeaders(action, sequenceNumber)
+	resp, err := doArmStorageBlobSASRequest(blobClient, container, name, "&comp=properties", headers)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q setting sequence number", resp.Status)
+	}
+	return nil
+}
+
+// flattenArmStorageBlobSequenceNumber returns the sequence number of a page
+// blob to keep in state. With the max action Azure keeps the larger of its
+// own sequence number and the configured one, so a larger one isn't drift.
+func flattenArmStorageBlobSequenceNumber(action string, configured int, remote int64) int {
+	if action == "max" && remote > int64(configured) {
+		return configured
+	}
+	return int(remote)
+}
+
+// armStorageBlobSAS describes the shared access signature to build for a
+// blob: either an ad hoc one with its own expiry and permissions, or one which
+// takes them from a stored access policy on the container, so that it can be
+// revoked by changing the policy.
+type armStorageBlobSAS struct {
+	policyName  string
+	expiry      string
+	permissions string
+}
+
+// expandArmStorageBlobSAS returns the signature described by the sas block,
+// or nil if there is none. The block must give either a policy_name, or both
+// expiry and permissions.
+func expandArmStorageBlobSAS(d *schema.ResourceData) (*armStorageBlobSAS, error) {
+	blocks := d.Get("sas").([]interface{})
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	m, _ := blocks[0].(map[string]interface{})
+	sas := &armStorageBlobSAS{}
+	if m != nil {
+		sas.policyName = m["policy_name"].(string)
+		sas.expiry = resolveArmStorageBlobSASExpiry(m["expiry"].(string), time.Now())
+		sas.permissions = m["permissions"].(string)
+	}
+
+	if sas.policyName != "" {
+		if sas.expiry != "" || sas.permissions != "" {
+			return nil, fmt.Errorf("sas cannot set expiry or permissions alongside policy_name, they are taken from the policy")
+		}
+	} else if sas.expiry == "" || sas.permissions == "" {
+		return nil, fmt.Errorf("sas must set either policy_name, or both expiry and permissions")
+	}
+
+	return sas, nil
+}
+
+// resolveArmStorageBlobSASExpiry returns the time a SAS with the given expiry
+// expires. An expiry given as a duration counts from now, the time the
+// signature is built, so a new signature is built on every refresh.
+func resolveArmStorageBlobSASExpiry(expiry string, now time.Time) string {
+	if duration, err := time.ParseDuration(expiry); err == nil {
+		return now.Add(duration).UTC().Format(time.RFC3339)
+	}
+	return expiry
+}
+
+// signArmStorageBlobSAS returns blobURL with a shared access signature for
+// sas, signed with the base64 encoded key of the storage account. The SDK's
+// GetBlobSASURI can't refer to a stored access policy, so the signature is
+// built here for both kinds, following the 2014-02-14 string to sign.
+func signArmStorageBlobSAS(sas *armStorageBlobSAS, accountName, key, blobURL string) (string, error) {
+	u, err := url.Parse(blobURL)
+	if err != nil {
+		return "", err
+	}
+
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", fmt.Errorf("Error decoding storage account key: %s", err)
+	}
+
+	version := storage.DefaultAPIVersion
+	stringToSign := strings.Join([]string{
+		sas.permissions,
+		"", // start
+		sas.expiry,
+		"/" + accountName + u.Path,
+		sas.policyName,
+		version,
+		"", "", "", "", "", // response header overrides
+	}, "\n")
+
+	h := hmac.New(sha256.New, decodedKey)
+	h.Write([]byte(stringToSign))
+
+	params := url.Values{
+		"sv":  {version},
+		"sr":  {"b"},
+		"sig": {base64.StdEncoding.EncodeToString(h.Sum(nil))},
+	}
+	if sas.policyName != "" {
+		params.Set("si", sas.policyName)
+	}
+	if sas.expiry != "" {
+		params.Set("se", sas.expiry)
+	}
+	if sas.permissions != "" {
+		params.Set("sp", sas.permissions)
+	}
+
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+// armStorageBlobCopyTimeout is how long a copy from source_uri may take when
+// neither copy_timeout nor upload_timeout is set.
+const armStorageBlobCopyTimeout = 60 * time.Minute
+
+// armStorageBlobCopyPollInterval is how often the status of a copy is checked
+// when no copy_poll_interval is set.
+const armStorageBlobCopyPollInterval = 5 * time.Second
+
+// armStorageBlobCopyOptions controls how a copy is waited for.
+type armStorageBlobCopyOptions struct {
+	// timeout is how long the copy may take before it is aborted
+	timeout time.Duration
+	// pollInterval is how often the status of the copy is checked
+	pollInterval time.Duration
+	// wait is whether to wait for the copy to finish at all. A copy which
+	// isn't waited for is left pending, for copy_status to follow.
+	wait bool
+}
+
+// expandArmStorageBlobCopyOptions returns the copy options configured by
+// copy_timeout, falling back to upload_timeout, copy_poll_interval and
+// wait_for_copy. The durations were validated when they were configured.
+func expandArmStorageBlobCopyOptions(d *schema.ResourceData) armStorageBlobCopyOptions {
+	opts := armStorageBlobCopyOptions{
+		timeout:      armStorageBlobCopyTimeout,
+		pollInterval: armStorageBlobCopyPollInterval,
+		wait:         d.Get("wait_for_copy").(bool),
+	}
+	if v := d.Get("copy_timeout").(string); v != "" {
+		opts.timeout, _ = time.ParseDuration(v)
+	} else if v := d.Get("upload_timeout").(string); v != "" {
+		opts.timeout, _ = time.ParseDuration(v)
+	}
+	if v := d.Get("copy_poll_interval").(string); v != "" {
+		opts.pollInterval, _ = time.ParseDuration(v)
+	}
+
+	return opts
+}
+
+// copyArmStorageBlob copies the blob at sourceURI, which may be in another
+// storage account if it includes a SAS token, and unless opts say otherwise
+// waits for the copy to finish. The SDK's CopyBlob waits forever and doesn't
+// return the copy ID, so the copy is started with a shared access signature
+// instead. A copy that fails or times out is aborted and the destination blob
+// deleted, on a best effort basis.
+func copyArmStorageBlob(blobClient *storage.BlobStorageClient, container, name, sourceURI string, opts armStorageBlobCopyOptions) error {
+	resp, err := doArmStorageBlobSASRequest(blobClient, container, name, "", map[string]string{
+		"x-ms-copy-source": sourceURI,
+	})
+	if err != nil {
+		return fmt.Errorf("Error starting copy of %q: %s", sourceURI, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("Error starting copy of %q: unexpected status %q", sourceURI, resp.Status)
+	}
+	copyID := resp.Header.Get("x-ms-copy-id")
+	if copyID == "" {
+		return fmt.Errorf("Error starting copy of %q: no copy ID returned", sourceURI)
+	}
+
+	if !opts.wait {
+		log.Printf("[INFO] Not waiting for copy %q of %q to storage blob %q", copyID, sourceURI, name)
+		return nil
+	}
+
+	log.Printf("[INFO] Waiting for copy %q of %q to storage blob %q", copyID, sourceURI, name)
+	if err := waitForArmStorageBlobCopy(blobClient, container, name, copyID, opts.timeout, opts.pollInterval); err != nil {
+		abortArmStorageBlobCopy(blobClient, container, name, copyID)
+		return fmt.Errorf("Error waiting for copy of %q: %s", sourceURI, err)
+	}
+
+	return nil
+}
+
+// waitForArmStorageBlobCopy checks the status of a copy every pollInterval
+// until it succeeds, fails, or timeout passes. The error for a copy which
+// times out includes how far it got.
+func waitForArmStorageBlobCopy(blobClient armStorageBlobPropertiesClient, container, name, copyID string, timeout, pollInterval time.Duration) error {
+	refresh := armStorageBlobCopyStateRefreshFunc(blobClient, container, name, copyID)
+	deadline := time.Now().Add(timeout)
+	for {
+		result, state, err := refresh()
+		if err != nil {
+			return err
+		}
+		switch state {
+		case "success":
+			return nil
+		case "pending":
+		default:
+			return fmt.Errorf("unexpected copy status %q", state)
+		}
+
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			// Copy progress is reported as "<bytes copied>/<total bytes>"
+			if progress := result.(*storage.BlobProperties).CopyProgress; progress != "" {
+				return fmt.Errorf("timeout after %s, with %s bytes copied", timeout, progress)
+			}
+			return fmt.Errorf("timeout after %s", timeout)
+		}
+
+		wait := pollInterval
+		if wait > remaining {
+			wait = remaining
+		}
+		log.Printf("[DEBUG] Copy %q to storage blob %q is pending, checking again in %s", copyID, name, wait)
+		time.Sleep(wait)
+	}
+}
+
+// abortArmStorageBlobCopy stops a pending copy and deletes the blob it was
+// copying to. Aborting is best effort: failures are logged, not returned.
+func abortArmStorageBlobCopy(blobClient *storage.BlobStorageClient, container, name, copyID string) {
+	resp, err := doArmStorageBlobSASRequest(blobClient, container, name, "&comp=copy&copyid="+url.QueryEscape(copyID), map[string]string{
+		"x-ms-copy-action": "abort",
+	})
+	if err != nil {
+		log.Printf("[WARN] Error aborting copy %q to storage blob %q: %s", copyID, name, err)
+	} else {
+		resp.Body.Close()
+		// A copy which has already finished can't be aborted, and is
+		// deleted all the same
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusConflict {
+			log.Printf("[WARN] Unexpected status %q aborting copy %q to storage blob %q", resp.Status, copyID, name)
+		}
+	}
+
+	if _, err := blobClient.DeleteBlobIfExists(container, name); err != nil {
+		log.Printf("[WARN] Error deleting partially copied storage blob %q: %s", name, err)
+	}
+}
+
+// armStorageBlobPropertiesClient is the subset of the blob storage client used
+// to follow the progress of a copy.
+type armStorageBlobPropertiesClient interface {
+	GetBlobProperties(container, name string) (*storage.BlobProperties, error)
+}
+
+func armStorageBlobCopyStateRefreshFunc(blobClient armStorageBlobPropertiesClient, container, name, copyID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		props, err := blobClient.GetBlobProperties(container, name)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if props.CopyID != copyID {
+			return nil, "", fmt.Errorf("copy %q was replaced by copy %q", copyID, props.CopyID)
+		}
+		if props.CopyStatus == "failed" || props.CopyStatus == "aborted" {
+			return nil, "", fmt.Errorf("copy %s: %s", props.CopyStatus, props.CopyStatusDescription)
+		}
+
+		return props, props.CopyStatus, nil
+	}
+}
+
+// checkArmStorageBlobRecentlyModified returns an error if the blob was
+// modified within window of now. A blob which no longer exists has nothing
+// to protect.
+func checkArmStorageBlobRecentlyModified(blobClient armStorageBlobPropertiesClient, container, name string, window time.Duration, now time.Time, maxRetries int) error {
+	var props *storage.BlobProperties
+	err := retryArmStorageBlobOperation(maxRetries, fmt.Sprintf("read of the properties of storage blob %q", name), func() error {
+		var err error
+		props, err = blobClient.GetBlobProperties(container, name)
+		return err
+	})
+	if err != nil {
+		if isArmStorageBlobNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("Error retrieving properties of storage blob %q: %s", name, err)
+	}
+
+	modified, err := time.Parse(http.TimeFormat, props.LastModified)
+	if err != nil {
+		return fmt.Errorf("Error parsing last modified time %q of storage blob %q: %s", props.LastModified, name, err)
+	}
+
+	if age := now.Sub(modified); age < window {
+		return fmt.Errorf("Refusing to delete storage blob %q, it was modified %s ago, within prevent_destroy_if_recently_modified (%s). Set force_destroy to delete it anyway", name, age-age%time.Second, window)
+	}
+
+	return nil
+}
+
+func isArmStorageBlobNotFound(err error) bool {
+	switch e := err.(type) {
+	case storage.AzureStorageServiceError:
+		return e.StatusCode == http.StatusNotFound
+	case storage.UnexpectedStatusCodeError:
+		return e.Got() == http.StatusNotFound
+	}
+	return false
+}
+
+// deleteArmStorageBlob deletes a blob, along with its snapshots when
+// deleteSnapshots is "include", or only its snapshots when it is "only". The
+// SDK's DeleteBlobIfExists can't send the x-ms-delete-snapshots header, so
+// unless deleteSnapshots is "none" the blob is deleted with a short lived
+// shared access signature instead. A blob which doesn't exist is not an error.
+func deleteArmStorageBlob(blobClient *storage.BlobStorageClient, container, name, deleteSnapshots string) error {
+	if deleteSnapshots == "none" {
+		_, err := blobClient.DeleteBlobIfExists(container, name)
+		return err
+	}
+
+	uri, err := blobClient.GetBlobSASURI(container, name, time.Now().Add(15*time.Minute), "d")
+	if err != nil {
+		return err
+	}
+
+	return deleteArmStorageBlobURI(uri, deleteSnapshots)
+}
+
+// deleteArmStorageBlobURI sends a Delete Blob request with the given
+// x-ms-delete-snapshots option to uri. A rejected delete, for instance of a
+// leased blob, returns the service's error as a
+// storage.AzureStorageServiceError.
+func deleteArmStorageBlobURI(uri, deleteSnapshots string) error {
+	req, err := http.NewRequest("DELETE", uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-ms-delete-snapshots", deleteSnapshots)
+	req.Header.Set("x-ms-version", storage.DefaultAPIVersion)
+
+	resp, err := armStorageBlobHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	serviceErr := storage.AzureStorageServiceError{}
+	if err := xml.NewDecoder(resp.Body).Decode(&serviceErr); err != nil {
+		return fmt.Errorf("unexpected status %q deleting blob", resp.Status)
+	}
+	serviceErr.StatusCode = resp.StatusCode
+	serviceErr.RequestID = resp.Header.Get("x-ms-request-id")
+	return serviceErr
+}
+
+func validateArmStorageBlobExpectedMD5(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if sum, err := base64.StdEncoding.DecodeString(value); err != nil || len(sum) != md5.Size {
+		errors = append(errors, fmt.Errorf("Blob expected_md5 %q is invalid, must be a base64 encoded MD5 hash", value))
+	}
+
+	return
+}
+
+// verifyArmStorageBlobExpectedMD5 compares the MD5 of the content about to be
+// uploaded, from content_base64 or source, with expected_md5, so that content
+// which isn't what was expected is never uploaded.
+func verifyArmStorageBlobExpectedMD5(d *schema.ResourceData, content []byte) error {
+	expected := d.Get("expected_md5").(string)
+	if expected == "" {
+		return nil
+	}
+
+	var actual string
+	if source := d.Get("source").(string); source != "" {
+		var err error
+		actual, err = armStorageBlobSourceMD5(source, d.Get("decompress").(bool))
+		if err != nil {
+			return err
+		}
+	} else {
+		sum := md5.Sum(content)
+		actual = base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	if actual != expected {
+		return fmt.Errorf("content has MD5 %q, but expected_md5 is %q", actual, expected)
+	}
+	return nil
+}
+
+func validateArmStorageBlobContentBase64(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := base64.StdEncoding.DecodeString(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not valid base64: %s", k, err))
+	}
+
+	return
+}
+
+// validateArmStorageBlobContent checks the decoded content of a blob against
+// its type and declared size. Block blobs are sized by their content, so a
+// size may not be given alongside it, whilst the content of a page blob must
+// be 512-byte aligned and fit within the size of the blob.
+func validateArmStorageBlobContent(blobType string, size int, content []byte) error {
+	switch strings.ToLower(blobType) {
+	case "page":
+		if len(content)%512 != 0 {
+			return fmt.Errorf("content_base64 decodes to %d bytes, page blob content must be a multiple of 512 bytes", len(content))
+		}
+		if len(content) > size {
+			return fmt.Errorf("content_base64 decodes to %d bytes, which exceeds the page blob size of %d", len(content), size)
+		}
+	default:
+		if size != 0 {
+			return fmt.Errorf("size cannot be set alongside content_base64 on block blobs")
+		}
+	}
+
+	return nil
+}
+
+// armStorageBlobPageWriteSize is the largest range which can be written to a
+// page blob in a single Put Page operation.
+const armStorageBlobPageWriteSize = storage.MaxBlobPageSize
+
+// armStorageBlobPageSize is the alignment of the ranges written to a page
+// blob.
+const armStorageBlobPageSize = 512
+
+// armStoragePageBlobClient is the subset of the blob storage client used to
+// write the pages of a page blob.
+type armStoragePageBlobClient interface {
+	PutPage(container, name string, startByte, endByte int64, writeType storage.PageWriteType, chunk []byte) error
+}
+
+// uploadArmStorageBlobPages writes source to the pages of a page blob of the
+// given size. A final range which doesn't fill a page is padded with zeroes.
+// Pages which are all zero are skipped, since a new page blob reads as zeroes
+// already, so sparse sources such as VHDs only write the ranges holding data.
+func uploadArmStorageBlobPages(blobClient armStoragePageBlobClient, container, name string, source io.Reader, size int64) error {
+	buf := make([]byte, armStorageBlobPageWriteSize)
+
+	var offset int64
+	for {
+		n, err := io.ReadFull(source, buf)
+		if n > 0 {
+			if offset+int64(n) > size {
+				return fmt.Errorf("Error uploading page blob %q: source is larger than the blob size of %d bytes", name, size)
+			}
+
+			// Only the final read can be short of a whole page
+			padded := n
+			if rem := n % armStorageBlobPageSize; rem != 0 {
+				padded += armStorageBlobPageSize - rem
+				if offset+int64(padded) > size {
+					return fmt.Errorf("Error uploading page blob %q: source of %d bytes is not a multiple of %d bytes and cannot be padded within the blob size of %d bytes",
+						name, offset+int64(n), armStorageBlobPageSize, size)
+				}
+				for i := n; i < padded; i++ {
+					buf[i] = 0
+				}
+			}
+
+			if err := putArmStorageBlobPageRanges(blobClient, container, name, offset, buf[:padded]); err != nil {
+				return err
+			}
+			offset += int64(padded)
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Error reading source of page blob %q: %s", name, err)
+		}
+	}
+}
+
+// putArmStorageBlobPageRanges writes each run of pages in chunk which holds
+// data, skipping pages which are all zero. chunk starts at offset bytes into
+// the blob and is a whole number of pages.
+func putArmStorageBlobPageRanges(blobClient armStoragePageBlobClient, container, name string, offset int64, chunk []byte) error {
+	start := -1
+	for page := 0; page <= len(chunk); page += armStorageBlobPageSize {
+		empty := page == len(chunk) || isArmStorageBlobPageEmpty(chunk[page:page+armStorageBlobPageSize])
+		if !empty && start == -1 {
+			start = page
+		}
+		if empty && start != -1 {
+			first, last := offset+int64(start), offset+int64(page)-1
+			log.Printf("[DEBUG] Writing bytes %d-%d of page blob %q", first, last, name)
+			err := blobClient.PutPage(container, name, first, last, storage.PageWriteTypeUpdate, chunk[start:page])
+			if err != nil {
+				return fmt.Errorf("Error writing bytes %d-%d of page blob %q: %s", first, last, name, err)
+			}
+			start = -1
+		}
+	}
+
+	return nil
+}
+
+func isArmStorageBlobPageEmpty(page []byte) bool {
+	for _, b := range page {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// armStorageBlockBlobClient is the subset of the blob storage client used to
+// upload block blobs in blocks.
+type armStorageBlockBlobClient interface {
+	PutBlock(container, name, blockID string, chunk []byte) error
+	PutBlockWithLength(container, name, blockID string, size uint64, blob io.Reader, extraHeaders map[string]string) error
+	PutBlockList(container, name string, blocks []storage.Block) error
+	GetBlockList(container, name string, blockType storage.BlockListType) (storage.BlockListResponse, error)
+	DeleteBlobIfExists(container, name string) (bool, error)
+}
+
+// armStorageBlobUploadOptions controls how a blob is uploaded in blocks.
+type armStorageBlobUploadOptions struct {
+	// progressInterval is the number of bytes between the progress lines
+	// logged during the upload, or 0 to log no progress.
+	progressInterval int64
+
+	// deadline is the time by which the upload must have finished, or the
+	// zero time for no deadline.
+	deadline time.Time
+
+	// replacing is set when the upload replaces the content of an existing
+	// blob, which must be left intact if the upload fails.
+	replacing bool
+
+	// validateBlocks sends the MD5 of each block with it, so that Azure
+	// rejects a block corrupted in transit.
+	validateBlocks bool
+
+	// parallelism is the number of blocks uploaded at once. Values below 1
+	// upload one block at a time.
+	parallelism int
+
+	// useMmap reads a source which isn't decompressed by memory mapping it,
+	// where the platform and the file allow it.
+	useMmap bool
+
+	// blockSize is the size of the blocks the source is split into, or 0
+	// for armStorageBlobBlockSize. It is increased for a source which would
+	// otherwise need more than armStorageBlobMaxBlocks blocks.
+	blockSize int
+}
+
+// armStorageBlobMaxParallelism is the most blocks that can be uploaded at once
+// for a blob. Each block in flight holds a 4MB buffer.
+const armStorageBlobMaxParallelism = 64
+
+// armStorageBlobParallelism returns the number of blocks to upload at once for
+// the blob, which is its own parallelism if set, or the provider default.
+func armStorageBlobParallelism(d *schema.ResourceData, armClient *ArmClient) int {
+	if v, ok := d.GetOk("parallelism"); ok {
+		return v.(int)
+	}
+	return armClient.blobParallelism
+}
+
+// armStorageBlobBlockSize is the size of the blocks a source is split into
+// when it is uploaded to a block blob, unless block_size_bytes is set. It is
+// also the largest block the storage API version used by the SDK accepts.
+const armStorageBlobBlockSize = storage.MaxBlobBlockSize
+
+// armStorageBlobMinBlockSize is the smallest block_size_bytes allowed.
+const armStorageBlobMinBlockSize = 4 * 1024
+
+// armStorageBlobMaxBlocks is the most blocks a block blob can be committed
+// with. It is a variable so that tests can lower it.
+var armStorageBlobMaxBlocks = 50000
+
+// armStorageBlobEffectiveBlockSize returns the size of the blocks to split a
+// source of total bytes into: blockSize, or armStorageBlobBlockSize if it is
+// 0, increased to a multiple of armStorageBlobMinBlockSize large enough for
+// the source to fit in armStorageBlobMaxBlocks blocks. A source whose size
+// isn't known, with total -1, is split into blocks of the size asked for.
+func armStorageBlobEffectiveBlockSize(total int64, blockSize int) (int, error) {
+	if blockSize <= 0 {
+		blockSize = armStorageBlobBlockSize
+	}
+	if total < 0 {
+		return blockSize, nil
+	}
+
+	maxBlocks := int64(armStorageBlobMaxBlocks)
+	if limit := maxBlocks * armStorageBlobBlockSize; total > limit {
+		return 0, fmt.Errorf("source is %d bytes, more than the %d bytes a block blob can hold", total, limit)
+	}
+
+	if minSize := (total + maxBlocks - 1) / maxBlocks; minSize > int64(blockSize) {
+		blockSize = int((minSize + armStorageBlobMinBlockSize - 1) / armStorageBlobMinBlockSize * armStorageBlobMinBlockSize)
+	}
+
+	return blockSize, nil
+}
+
+// armStorageBlobBlockID returns the ID of the block starting at offset bytes
+// into a blob. Deriving the ID from the offset rather than from the order in
+// which blocks are uploaded means that every upload of the same content, in
+// sequence or in parallel, names its blocks alike. All of the block IDs of a
+// blob must have the same length, so the offset is zero-padded.
+func armStorageBlobBlockID(offset int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("blobid-%020d", offset)))
+}
+
+// openArmStorageBlobSource opens the local file to upload to a blob. When
+// decompress is set, the file must be gzipped and is decompressed as it is
+// read.
+func openArmStorageBlobSource(path string, decompress bool) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening source %q: %s", path, err)
+	}
+
+	if !decompress {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Error reading source %q: not valid gzip: %s", path, err)
+	}
+
+	return &armStorageBlobGzipSource{Reader: reader, file: file}, nil
+}
+
+// armStorageBlobGzipSource closes both the gzip reader and the file beneath
+// it.
+type armStorageBlobGzipSource struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (s *armStorageBlobGzipSource) Close() error {
+	s.Reader.Close()
+	return s.file.Close()
+}
+
+// uploadArmStorageBlobSource uploads the file at path as the blocks of a blob,
+// returning the base64 encoded MD5 of the uploaded content.
+func uploadArmStorageBlobSource(blobClient armStorageBlockBlobClient, container, name, path string, decompress bool, opts armStorageBlobUploadOptions) (string, error) {
+	var source io.ReadCloser
+	if opts.useMmap && !decompress {
+		var err error
+		if source, err = mmapArmStorageBlobSource(path); err != nil {
+			log.Printf("[INFO] Unable to memory map source %q, reading it instead: %s", path, err)
+		}
+	}
+	if source == nil {
+		var err error
+		if source, err = openArmStorageBlobSource(path, decompress); err != nil {
+			return "", err
+		}
+	}
+	defer source.Close()
+
+	// The size of a decompressed source isn't known until it has been read
+	total := int64(-1)
+	if !decompress {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("Error reading source %q: %s", path, err)
+		}
+		total = info.Size()
+	}
+
+	hash := md5.New()
+	if err := uploadArmStorageBlobBlocks(blobClient, container, name, io.TeeReader(source, hash), total, opts); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// armStorageBlobSourceMD5 returns the base64 encoded MD5 of the content which
+// would be uploaded from the file at path.
+func armStorageBlobSourceMD5(path string, decompress bool) (string, error) {
+	source, err := openArmStorageBlobSource(path, decompress)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, source); err != nil {
+		return "", fmt.Errorf("Error reading source %q: %s", path, err)
+	}
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+func uploadArmStorageBlobPageSource(blobClient armStoragePageBlobClient, container, name, path string, decompress bool, size int64) error {
+	source, err := openArmStorageBlobSource(path, decompress)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	return uploadArmStorageBlobPages(blobClient, container, name, source, size)
+}
+
+// armStorageBlobProgress logs how much of a blob has been uploaded, once for
+// every interval bytes. Blobs smaller than the interval log nothing, so that
+// applies creating many small blobs aren't flooded with progress lines.
+type armStorageBlobProgress struct {
+	mu       sync.Mutex
+	name     string
+	total    int64 // -1 when the size of the blob is not known up front
+	interval int64
+	uploaded int64
+	next     int64
+}
+
+func newArmStorageBlobProgress(name string, total, interval int64) *armStorageBlobProgress {
+	return &armStorageBlobProgress{
+		name:     name,
+		total:    total,
+		interval: interval,
+		next:     interval,
+	}
+}
+
+// add records that n more bytes have been uploaded. It is safe to call on a
+// nil *armStorageBlobProgress, which logs nothing, and from several
+// goroutines at once.
+func (p *armStorageBlobProgress) add(n int) {
+	if p == nil || p.interval <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.uploaded += int64(n)
+	if p.uploaded < p.next {
+		return
+	}
+	p.next = (p.uploaded/p.interval + 1) * p.interval
+
+	if p.total >= 0 {
+		log.Printf("[INFO] Uploaded %d of %d bytes of storage blob %q", p.uploaded, p.total, p.name)
+	} else {
+		log.Printf("[INFO] Uploaded %d bytes of storage blob %q", p.uploaded, p.name)
+	}
+}
+
+// armStorageBlobBufferPool holds block sized buffers shared by all blob
+// uploads, so that an apply creating many blobs doesn't allocate a new block
+// buffer for each of them.
+//
+// A buffer is only returned to the pool once the block read into it has been
+// uploaded. PutBlock doesn't return until its request has completed, so no
+// in-flight request still holds a slice of a buffer in the pool.
+//
+// The pool holds pointers, as putting a slice into it would allocate.
+var armStorageBlobBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, armStorageBlobBlockSize)
+		return &buf
+	},
+}
+
+// uploadArmStorageBlobBlocks reads source in blocks, uploads each of them and
+// then commits the blocks in order as the content of the blob. total is the
+// size of source, or -1 if it isn't known, and is only used to report
+// progress.
+//
+// If the upload fails or passes its deadline, the blocks already uploaded are
+// cleaned up, so that no uncommitted blocks are left behind to be billed.
+func uploadArmStorageBlobBlocks(blobClient armStorageBlockBlobClient, container, name string, source io.Reader, total int64, opts armStorageBlobUploadOptions) error {
+	if err := putArmStorageBlobBlocks(blobClient, container, name, source, total, opts); err != nil {
+		if opts.replacing {
+			restoreArmStorageBlobBlocks(blobClient, container, name)
+		} else {
+			cleanupArmStorageBlobBlocks(blobClient, container, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// cleanupArmStorageBlobBlocks discards the uncommitted blocks of a new blob
+// whose upload failed. Uncommitted blocks can't be deleted directly, so an
+// empty block list is committed, discarding them, and the empty blob is
+// deleted. Cleanup is best effort: failures are logged, not returned.
+func cleanupArmStorageBlobBlocks(blobClient armStorageBlockBlobClient, container, name string) {
+	log.Printf("[INFO] Cleaning up uncommitted blocks of storage blob %q", name)
+	if err := blobClient.PutBlockList(container, name, []storage.Block{}); err != nil {
+		log.Printf("[WARN] Error discarding uncommitted blocks of storage blob %q: %s", name, err)
+	}
+	if _, err := blobClient.DeleteBlobIfExists(container, name); err != nil {
+		log.Printf("[WARN] Error deleting partially uploaded storage blob %q: %s", name, err)
+	}
+}
+
+// restoreArmStorageBlobBlocks discards the uncommitted blocks of an existing
+// blob whose new content failed to upload, by committing its current block
+// list again. A blob which was put in one operation has no block list to
+// commit, so its uncommitted blocks are left for Azure to discard after a
+// week rather than risk its content. Restoring is best effort: failures are
+// logged, not returned.
+func restoreArmStorageBlobBlocks(blobClient armStorageBlockBlobClient, container, name string) {
+	list, err := blobClient.GetBlockList(container, name, storage.BlockListTypeCommitted)
+	if err != nil {
+		log.Printf("[WARN] Error reading the block list of storage blob %q, leaving its uncommitted blocks: %s", name, err)
+		return
+	}
+	if len(list.CommittedBlocks) == 0 {
+		log.Printf("[WARN] Storage blob %q has no committed blocks to restore, leaving its uncommitted blocks", name)
+		return
+	}
+
+	blocks := make([]storage.Block, 0, len(list.CommittedBlocks))
+	for _, b := range list.CommittedBlocks {
+		blocks = append(blocks, storage.Block{
+			ID:     b.Name,
+			Status: storage.BlockStatusCommitted,
+		})
+	}
+
+	log.Printf("[INFO] Discarding uncommitted blocks of storage blob %q", name)
+	if err := blobClient.PutBlockList(container, name, blocks); err != nil {
+		log.Printf("[WARN] Error discarding uncommitted blocks of storage blob %q: %s", name, err)
+	}
+}
+
+// armStorageBlobOperation is a kind of operation on a blob, each of which
+// has its own retry budget.
+type armStorageBlobOperation int
+
+const (
+	armStorageBlobWrite armStorageBlobOperation = iota
+	armStorageBlobRead
+	armStorageBlobDelete
+)
+
+// armStorageBlobMaxRetries returns the number of times to retry the requests
+// of an operation on the blob: the provider's budget for the operation, or
+// for writes, the blob's own max_retries if set.
+func armStorageBlobMaxRetries(d *schema.ResourceData, armClient *ArmClient, op armStorageBlobOperation) int {
+	if op == armStorageBlobWrite {
+		if v, ok := d.GetOk("max_retries"); ok {
+			return v.(int)
+		}
+	}
+	return armClient.blobRetries[op]
+}
+
+// armStorageBlobRetryDelay is how long the first retry of a failed blob
+// operation waits. The delay doubles with each retry, up to
+// armStorageBlobMaxRetryDelay.
+var armStorageBlobRetryDelay = 1 * time.Second
+
+const armStorageBlobMaxRetryDelay = 30 * time.Second
+
+// retryArmStorageBlobOperation runs op, retrying it up to maxRetries times
+// with exponential backoff for as long as it fails with a transient error.
+// Any other error, such as a 403 or a 404, is returned at once.
+func retryArmStorageBlobOperation(maxRetries int, description string, op func() error) error {
+	delay := armStorageBlobRetryDelay
+	for retry := 0; ; retry++ {
+		err := op()
+		if err == nil || !isArmStorageBlobErrorRetryable(err) {
+			return err
+		}
+		if retry >= maxRetries {
+			if retry > 0 {
+				return fmt.Errorf("%s (gave up after %d retries)", err, retry)
+			}
+			return err
+		}
+
+		log.Printf("[WARN] Retrying %s in %s (%d of %d): %s", description, delay, retry+1, maxRetries, err)
+		time.Sleep(delay)
+		if delay *= 2; delay > armStorageBlobMaxRetryDelay {
+			delay = armStorageBlobMaxRetryDelay
+		}
+	}
+}
+
+// isArmStorageBlobErrorRetryable reports whether err is a transient failure
+// of the storage service or of the connection to it: a 500 or 503 from the
+// service, a connection reset or timeout, or a response cut short.
+func isArmStorageBlobErrorRetryable(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		if opErr.Timeout() {
+			return true
+		}
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+
+	switch e := err.(type) {
+	case storage.AzureStorageServiceError:
+		return e.StatusCode == http.StatusInternalServerError || e.StatusCode == http.StatusServiceUnavailable
+	case storage.UnexpectedStatusCodeError:
+		return e.Got() == http.StatusInternalServerError || e.Got() == http.StatusServiceUnavailable
+	case syscall.Errno:
+		return e == syscall.ECONNRESET || e.Timeout()
+	case net.Error:
+		return e.Timeout()
+	}
+
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// retryingArmStorageBlockBlobClient retries the block uploads and commits
+// made through an armStorageBlockBlobClient which fail with a transient
+// error.
+type retryingArmStorageBlockBlobClient struct {
+	armStorageBlockBlobClient
+	maxRetries int
+}
+
+func (c retryingArmStorageBlockBlobClient) PutBlock(container, name, blockID string, chunk []byte) error {
+	return retryArmStorageBlobOperation(c.maxRetries, fmt.Sprintf("upload of block %q of storage blob %q", blockID, name), func() error {
+		return c.armStorageBlockBlobClient.PutBlock(container, name, blockID, chunk)
+	})
+}
 
-	if value%512 != 0 {
-		errors = append(errors, fmt.Errorf("Blob Size %q is invalid, must be a multiple of 512", value))
+// PutBlockWithLength can only retry a block whose body can be rewound, which
+// all of the bodies sent by this resource can.
+func (c retryingArmStorageBlockBlobClient) PutBlockWithLength(container, name, blockID string, size uint64, blob io.Reader, extraHeaders map[string]string) error {
+	seeker, ok := blob.(io.Seeker)
+	if !ok {
+		return c.armStorageBlockBlobClient.PutBlockWithLength(container, name, blockID, size, blob, extraHeaders)
 	}
 
-	return
+	return retryArmStorageBlobOperation(c.maxRetries, fmt.Sprintf("upload of block %q of storage blob %q", blockID, name), func() error {
+		if _, err := seeker.Seek(0, 0); err != nil {
+			return err
+		}
+		return c.armStorageBlockBlobClient.PutBlockWithLength(container, name, blockID, size, blob, extraHeaders)
+	})
+}
+
+func (c retryingArmStorageBlockBlobClient) PutBlockList(container, name string, blocks []storage.Block) error {
+	return retryArmStorageBlobOperation(c.maxRetries, fmt.Sprintf("commit of the blocks of storage blob %q", name), func() error {
+		return c.armStorageBlockBlobClient.PutBlockList(container, name, blocks)
+	})
+}
+
+// retryingArmStoragePageBlobClient retries the page writes made through an
+// armStoragePageBlobClient which fail with a transient error.
+type retryingArmStoragePageBlobClient struct {
+	armStoragePageBlobClient
+	maxRetries int
+}
+
+func (c retryingArmStoragePageBlobClient) PutPage(container, name string, startByte, endByte int64, writeType storage.PageWriteType, chunk []byte) error {
+	return retryArmStorageBlobOperation(c.maxRetries, fmt.Sprintf("write of pages %d-%d of storage blob %q", startByte, endByte, name), func() error {
+		return c.armStoragePageBlobClient.PutPage(container, name, startByte, endByte, writeType, chunk)
+	})
+}
+
+// putArmStorageBlobBlock uploads a single block. When validate is set the
+// block is sent with its Content-MD5, which Azure checks before storing it.
+func putArmStorageBlobBlock(blobClient armStorageBlockBlobClient, container, name, blockID string, chunk []byte, validate bool) error {
+	if !validate {
+		return blobClient.PutBlock(container, name, blockID, chunk)
+	}
+
+	sum := md5.Sum(chunk)
+	headers := map[string]string{
+		"Content-MD5": base64.StdEncoding.EncodeToString(sum[:]),
+	}
+	return blobClient.PutBlockWithLength(container, name, blockID, uint64(len(chunk)), bytes.NewReader(chunk), headers)
+}
+
+// armStorageBlobBlock is a block read from the source of a blob, waiting for
+// a worker to upload it.
+type armStorageBlobBlock struct {
+	index int
+	id    string
+	bufp  *[]byte
+	size  int
+}
+
+// putArmStorageBlobBlocks reads source in blocks and hands them to
+// opts.parallelism workers, which upload them concurrently. Each block is
+// read into its own buffer from the pool, so at most one buffer per worker,
+// and one being read, is in use at a time.
+//
+// The block list is built in the order the blocks are read, whatever order
+// they finish uploading in. The first block to fail stops the upload: no
+// more of source is read, blocks not yet started are skipped, and its error
+// is returned once the blocks in flight have finished.
+func putArmStorageBlobBlocks(blobClient armStorageBlockBlobClient, container, name string, source io.Reader, total int64, opts armStorageBlobUploadOptions) error {
+	progress := newArmStorageBlobProgress(name, total, opts.progressInterval)
+
+	blockSize, err := armStorageBlobEffectiveBlockSize(total, opts.blockSize)
+	if err != nil {
+		return fmt.Errorf("Error uploading storage blob %q: %s", name, err)
+	}
+	if opts.blockSize > 0 && blockSize != opts.blockSize {
+		log.Printf("[INFO] Increasing the block size of storage blob %q from %d to %d bytes, to upload it in at most %d blocks", name, opts.blockSize, blockSize, armStorageBlobMaxBlocks)
+	}
+
+	parallelism := opts.parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	// abort is closed by the first worker to fail, once its error is set
+	abort := make(chan struct{})
+	var failOnce sync.Once
+	var uploadErr error
+	fail := func(err error) {
+		failOnce.Do(func() {
+			uploadErr = err
+			close(abort)
+		})
+	}
+
+	var uploaded int64
+	var uploadedLock sync.Mutex
+
+	pending := make(chan armStorageBlobBlock)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range pending {
+				select {
+				case <-abort:
+				default:
+					log.Printf("[DEBUG] Uploading block %d (%d bytes) of storage blob %q", b.index, b.size, name)
+					if err := putArmStorageBlobBlock(blobClient, container, name, b.id, (*b.bufp)[:b.size], opts.validateBlocks); err != nil {
+						fail(fmt.Errorf("Error uploading block %d of storage blob %q: %s", b.index, name, err))
+					} else {
+						uploadedLock.Lock()
+						uploaded += int64(b.size)
+						uploadedLock.Unlock()
+						progress.add(b.size)
+					}
+				}
+				armStorageBlobBufferPool.Put(b.bufp)
+			}
+		}()
+	}
+
+	var blocks []storage.Block
+	var readErr error
+	var offset int64
+read:
+	for i := 0; ; i++ {
+		if !opts.deadline.IsZero() && time.Now().After(opts.deadline) {
+			uploadedLock.Lock()
+			readErr = fmt.Errorf("Error uploading storage blob %q: timed out after uploading %d bytes", name, uploaded)
+			uploadedLock.Unlock()
+			break
+		}
+
+		bufp := armStorageBlobBufferPool.Get().(*[]byte)
+		n, err := io.ReadFull(source, (*bufp)[:blockSize])
+		if n > 0 && len(blocks) == armStorageBlobMaxBlocks {
+			// Only a source whose size wasn't known up front gets here
+			armStorageBlobBufferPool.Put(bufp)
+			readErr = fmt.Errorf("Error uploading storage blob %q: source needs more than %d blocks of %d bytes", name, armStorageBlobMaxBlocks, blockSize)
+			break
+		}
+		if n > 0 {
+			blockID := armStorageBlobBlockID(offset)
+			offset += int64(n)
+			blocks = append(blocks, storage.Block{
+				ID:     blockID,
+				Status: storage.BlockStatusUncommitted,
+			})
+
+			select {
+			case pending <- armStorageBlobBlock{index: i, id: blockID, bufp: bufp, size: n}:
+			case <-abort:
+				armStorageBlobBufferPool.Put(bufp)
+				break read
+			}
+		} else {
+			armStorageBlobBufferPool.Put(bufp)
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			readErr = fmt.Errorf("Error reading source of storage blob %q: %s", name, err)
+			break
+		}
+	}
+
+	close(pending)
+	wg.Wait()
+
+	if uploadErr != nil {
+		return uploadErr
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	if err := blobClient.PutBlockList(container, name, blocks); err != nil {
+		return fmt.Errorf("Error committing blocks of storage blob %q: %s", name, err)
+	}
+
+	return nil
 }
 
 func validateArmStorageBlobType(v interface{}, k string) (ws []string, errors []error) {
@@ -80,6 +1899,19 @@ func validateArmStorageBlobType(v interface{}, k string) (ws []string, errors []
 	return
 }
 
+// validateArmStorageBlobAccountType checks that a blob of the given type can
+// be stored in an account of the given type. Premium storage accounts only
+// hold page blobs, and zone redundant accounts only hold block blobs.
+func validateArmStorageBlobAccountType(blobType string, accountType armStorage.AccountType) error {
+	switch {
+	case accountType == armStorage.PremiumLRS && strings.ToLower(blobType) != "page":
+		return fmt.Errorf("%s storage accounts only support page blobs, set type to \"page\" or use a standard storage account", accountType)
+	case accountType == armStorage.StandardZRS && strings.ToLower(blobType) != "blob":
+		return fmt.Errorf("%s storage accounts only support block blobs, set type to \"blob\" or use another account type", accountType)
+	}
+	return nil
+}
+
 func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) error {
 	armClient := meta.(*ArmClient)
 
@@ -92,18 +1924,49 @@ func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	name := d.Get("name").(string)
-	blobType := d.Get("type").(string)
 	cont := d.Get("storage_container_name").(string)
 
-	log.Printf("[INFO] Creating blob %q in storage account %q", name, storageAccountName)
-	switch strings.ToLower(blobType) {
-	case "block":
-		err = blobClient.CreateBlockBlob(cont, name)
-	case "page":
-		size := int64(d.Get("size").(int))
-		err = blobClient.PutPageBlob(cont, name, size, map[string]string{})
+	content, err := expandArmStorageBlobContent(d)
+	if err != nil {
+		return fmt.Errorf("Error creating storage blob %q: %s", name, err)
+	}
+
+	// Azure reports a missing container as a bare 404 on the upload, so it
+	// is checked for first to give a useful error
+	contExists, err := blobClient.ContainerExists(cont)
+	if err != nil {
+		return fmt.Errorf("Error checking if storage container %q exists: %s", cont, err)
 	}
+	if !contExists {
+		return fmt.Errorf("Error creating storage blob %q: storage container %q does not exist in storage account %q, it can be created with an azurerm_storage_container resource", name, cont, storageAccountName)
+	}
+
+	// Some account types only hold one type of blob, which Azure reports as
+	// a generic error on upload
+	account, err := armClient.storageServiceClient.GetProperties(resourceGroupName, storageAccountName)
 	if err != nil {
+		return fmt.Errorf("Error retrieving storage account %q: %s", storageAccountName, err)
+	}
+	if account.Properties != nil {
+		if err := validateArmStorageBlobAccountType(d.Get("type").(string), account.Properties.AccountType); err != nil {
+			return fmt.Errorf("Error creating storage blob %q in storage account %q: %s", name, storageAccountName, err)
+		}
+	}
+
+	if d.Get("write_once").(bool) {
+		exists, err := blobClient.BlobExists(cont, name)
+		if err != nil {
+			return fmt.Errorf("Error checking if storage blob %q exists: %s", name, err)
+		}
+		if exists {
+			log.Printf("[INFO] Storage blob %q already exists, adopting it without uploading", name)
+			d.SetId(name)
+			return resourceArmStorageBlobRead(d, meta)
+		}
+	}
+
+	log.Printf("[INFO] Creating blob %q in storage account %q", name, storageAccountName)
+	if err := writeArmStorageBlob(d, armClient, blobClient, content, false); err != nil {
 		return fmt.Errorf("Error creating storage blob on Azure: %s", err)
 	}
 
@@ -111,7 +1974,11 @@ func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) erro
 	return resourceArmStorageBlobRead(d, meta)
 }
 
-func resourceArmStorageBlobRead(d *schema.ResourceData, meta interface{}) error {
+// resourceArmStorageBlobUpdate uploads the blob's content again when it has
+// changed. The new content replaces the old in a single operation, either by
+// committing a new block list or by putting the blob again, so the blob keeps
+// its name and URL and is never seen half written.
+func resourceArmStorageBlobUpdate(d *schema.ResourceData, meta interface{}) error {
 	armClient := meta.(*ArmClient)
 
 	resourceGroupName := d.Get("resource_group_name").(string)
@@ -122,6 +1989,346 @@ func resourceArmStorageBlobRead(d *schema.ResourceData, meta interface{}) error
 		return err
 	}
 
+	name := d.Get("name").(string)
+	cont := d.Get("storage_container_name").(string)
+
+	if d.HasChange("content_base64") || d.HasChange("source") || d.HasChange("decompress") {
+		if d.Get("write_once").(bool) {
+			log.Printf("[INFO] Storage blob %q is write_once, ignoring the change to its content", name)
+		} else {
+			content, err := expandArmStorageBlobContent(d)
+			if err != nil {
+				return fmt.Errorf("Error updating storage blob %q: %s", name, err)
+			}
+
+			// Writing the content stores the blob's properties and metadata
+			// with it, so nothing is left to update
+			log.Printf("[INFO] Updating the content of blob %q in storage account %q", name, storageAccountName)
+			if err := writeArmStorageBlob(d, armClient, blobClient, content, true); err != nil {
+				return fmt.Errorf("Error updating storage blob on Azure: %s", err)
+			}
+
+			return resourceArmStorageBlobRead(d, meta)
+		}
+	}
+
+	if d.HasChange("custom_headers") || d.HasChange("content_type") || d.HasChange("cache_control") || d.HasChange("content_disposition") {
+		if err := validateArmStorageBlobHeaders(d); err != nil {
+			return fmt.Errorf("Error updating storage blob %q: %s", name, err)
+		}
+
+		log.Printf("[INFO] Updating the headers of blob %q in storage account %q", name, storageAccountName)
+		if err := setArmStorageBlobProperties(blobClient, cont, name, expandArmStorageBlobCustomHeaders(d)); err != nil {
+			return fmt.Errorf("Error updating headers of storage blob %q: %s", name, err)
+		}
+	}
+
+	if d.HasChange("sequence_number") || d.HasChange("sequence_number_action") {
+		if err := validateArmStorageBlobSequenceNumberSettings(d); err != nil {
+			return fmt.Errorf("Error updating storage blob %q: %s", name, err)
+		}
+
+		action := d.Get("sequence_number_action").(string)
+		log.Printf("[INFO] Updating the sequence number of blob %q in storage account %q (action: %s)", name, storageAccountName, action)
+		if err := setArmStorageBlobSequenceNumber(blobClient, cont, name, action, d.Get("sequence_number").(int)); err != nil {
+			return fmt.Errorf("Error updating sequence number of storage blob %q: %s", name, err)
+		}
+	}
+
+	if d.HasChange("metadata") {
+		log.Printf("[INFO] Updating the metadata of blob %q in storage account %q", name, storageAccountName)
+		if err := blobClient.SetBlobMetadata(cont, name, expandArmStorageBlobMetadata(d)); err != nil {
+			return fmt.Errorf("Error updating metadata of storage blob %q: %s", name, err)
+		}
+	}
+
+	return resourceArmStorageBlobRead(d, meta)
+}
+
+// validateArmStorageBlobSequenceNumberSettings checks that a sequence number
+// and the action applying it are only set on page blobs, and that they can be
+// sent to Azure together.
+func validateArmStorageBlobSequenceNumberSettings(d *schema.ResourceData) error {
+	sequenceNumber := d.Get("sequence_number").(int)
+	action := d.Get("sequence_number_action").(string)
+
+	if strings.ToLower(d.Get("type").(string)) != "page" && (sequenceNumber != 0 || action != "") {
+		return fmt.Errorf("sequence_number and sequence_number_action can only be set on page blobs")
+	}
+
+	// Azure rejects a sequence number sent with the increment action, and
+	// one set on creation would be incremented past the configured value
+	if action == "increment" && sequenceNumber != 0 {
+		return fmt.Errorf("sequence_number can't be set with the increment sequence_number_action")
+	}
+
+	return nil
+}
+
+// expandArmStorageBlobContent checks that the arguments of a blob are
+// consistent with each other and its type, and returns the decoded
+// content_base64, if any.
+func expandArmStorageBlobContent(d *schema.ResourceData) ([]byte, error) {
+	blobType := d.Get("type").(string)
+
+	if err := validateArmStorageBlobSequenceNumberSettings(d); err != nil {
+		return nil, err
+	}
+
+	// The size of a block blob is that of its content, so a size set on one
+	// would otherwise be silently ignored
+	if strings.ToLower(blobType) != "page" && d.Get("size").(int) != 0 {
+		return nil, fmt.Errorf("size can only be set on page blobs")
+	}
+
+	if d.Get("empty").(bool) && strings.ToLower(blobType) != "blob" {
+		return nil, fmt.Errorf("empty can only be set on blob type blobs")
+	}
+
+	if err := validateArmStorageBlobHeaders(d); err != nil {
+		return nil, err
+	}
+
+	if _, err := expandArmStorageBlobSAS(d); err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	if v, ok := d.GetOk("content_base64"); ok {
+		var err error
+		content, err = base64.StdEncoding.DecodeString(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding content_base64: %s", err)
+		}
+
+		if err := validateArmStorageBlobContent(blobType, d.Get("size").(int), content); err != nil {
+			return nil, err
+		}
+	}
+
+	source := d.Get("source").(string)
+	if source != "" {
+		switch strings.ToLower(blobType) {
+		case "page":
+			// The size of a decompressed source is only known once it has been
+			// read, so it is checked as the pages are written
+			if !d.Get("decompress").(bool) {
+				info, err := os.Stat(source)
+				if err != nil {
+					return nil, fmt.Errorf("Error reading source %q: %s", source, err)
+				}
+				if size := int64(d.Get("size").(int)); info.Size() > size {
+					return nil, fmt.Errorf("source is %d bytes, which exceeds the page blob size of %d", info.Size(), size)
+				}
+			}
+		}
+	} else if d.Get("decompress").(bool) {
+		return nil, fmt.Errorf("decompress can only be set alongside source")
+	}
+
+	if err := verifyArmStorageBlobExpectedMD5(d, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// writeArmStorageBlob puts the blob with the given content_base64 content, or
+// the content of its source, replacing any blob of the same name, and stores
+// its properties and metadata. With atomic_replace, an existing blob is
+// replaced by way of a temporary blob, see replaceArmStorageBlob.
+func writeArmStorageBlob(d *schema.ResourceData, armClient *ArmClient, blobClient *storage.BlobStorageClient, content []byte, replacing bool) error {
+	name := d.Get("name").(string)
+	cont := d.Get("storage_container_name").(string)
+
+	release := armClient.blobWriteLimiter.acquire(d.Get("storage_account_name").(string))
+	defer release()
+
+	put := func(blobName string, replacing bool) error {
+		if err := putArmStorageBlob(d, armClient, blobClient, blobName, content, replacing); err != nil {
+			return err
+		}
+
+		if metadata := expandArmStorageBlobMetadata(d); len(metadata) > 0 {
+			if err := blobClient.SetBlobMetadata(cont, blobName, metadata); err != nil {
+				return fmt.Errorf("Error setting metadata: %s", err)
+			}
+		}
+		return nil
+	}
+
+	if replacing && d.Get("atomic_replace").(bool) {
+		// The temporary blob is deleted once the copy over the blob is done,
+		// so it is always waited for
+		copyOpts := expandArmStorageBlobCopyOptions(d)
+		copyOpts.wait = true
+		copyOver := func(sourceURI string) error {
+			return copyArmStorageBlob(blobClient, cont, name, sourceURI, copyOpts)
+		}
+		putTemp := func(tempName string) error {
+			return put(tempName, false)
+		}
+		if err := replaceArmStorageBlob(blobClient, cont, name, putTemp, copyOver); err != nil {
+			return err
+		}
+	} else if err := put(name, replacing); err != nil {
+		return err
+	}
+
+	// The recorded hash belongs to the old content, so it must not be
+	// compared with the new one by verify_source
+	d.Set("content_md5", "")
+
+	return nil
+}
+
+// armStorageBlobReplaceClient is the subset of the blob storage client used to
+// replace a blob atomically.
+type armStorageBlobReplaceClient interface {
+	GetBlobSASURI(container, name string, expiry time.Time, permissions string) (string, error)
+	DeleteBlobIfExists(container, name string) (bool, error)
+}
+
+// armStorageBlobReplacePrefix starts the random suffix added to the name of a
+// blob to name the temporary blob its new content is uploaded to.
+const armStorageBlobReplacePrefix = ".tfreplace-"
+
+// replaceArmStorageBlob writes the new content of a blob with put to a
+// temporary blob next to it, then copies the temporary blob over the blob
+// with copyOver, which is given a read only shared access signature URI for it.
+// A copy within a storage account replaces the whole blob, content,
+// properties and metadata alike, so readers see either the old blob or the
+// new one, never a partly written one. The temporary blob is deleted whether
+// or not the replacement succeeds, on a best effort basis.
+func replaceArmStorageBlob(blobClient armStorageBlobReplaceClient, container, name string, put func(tempName string) error, copyOver func(sourceURI string) error) error {
+	tempName := resource.PrefixedUniqueId(name + armStorageBlobReplacePrefix)
+	defer func() {
+		if _, err := blobClient.DeleteBlobIfExists(container, tempName); err != nil {
+			log.Printf("[WARN] Error deleting temporary storage blob %q: %s", tempName, err)
+		}
+	}()
+
+	log.Printf("[INFO] Uploading the new content of storage blob %q to %q", name, tempName)
+	if err := put(tempName); err != nil {
+		return err
+	}
+
+	sourceURI, err := blobClient.GetBlobSASURI(container, tempName, time.Now().Add(armStorageBlobCopyTimeout), "r")
+	if err != nil {
+		return fmt.Errorf("Error signing temporary storage blob %q: %s", tempName, err)
+	}
+
+	log.Printf("[INFO] Replacing storage blob %q with %q", name, tempName)
+	return copyOver(sourceURI)
+}
+
+func putArmStorageBlob(d *schema.ResourceData, armClient *ArmClient, blobClient *storage.BlobStorageClient, name string, content []byte, replacing bool) error {
+	cont := d.Get("storage_container_name").(string)
+	source := d.Get("source").(string)
+
+	headers := expandArmStorageBlobCustomHeaders(d)
+	maxRetries := armStorageBlobMaxRetries(d, armClient, armStorageBlobWrite)
+
+	if sourceURI := d.Get("source_uri").(string); sourceURI != "" {
+		copyOpts := expandArmStorageBlobCopyOptions(d)
+		// A copy replaces the properties of the blob when it finishes, so
+		// they can only be set on a copy which is waited for
+		if !copyOpts.wait && len(headers) > 0 {
+			return fmt.Errorf("wait_for_copy must be true to set the headers of a blob copied from source_uri")
+		}
+		if err := copyArmStorageBlob(blobClient, cont, name, sourceURI, copyOpts); err != nil {
+			return err
+		}
+
+		// A copy takes the properties of its source, so any configured
+		// headers are set once it has finished
+		if len(headers) > 0 {
+			if err := setArmStorageBlobProperties(blobClient, cont, name, headers); err != nil {
+				return fmt.Errorf("Error setting properties: %s", err)
+			}
+		}
+		return nil
+	}
+
+	switch strings.ToLower(d.Get("type").(string)) {
+	case "blob":
+		if source != "" {
+			opts := armStorageBlobUploadOptions{
+				progressInterval: armClient.blobProgressThreshold,
+				replacing:        replacing,
+				validateBlocks:   d.Get("validate_blocks").(bool),
+				parallelism:      armStorageBlobParallelism(d, armClient),
+				useMmap:          d.Get("use_mmap").(bool),
+				blockSize:        d.Get("block_size_bytes").(int),
+			}
+			if v := d.Get("upload_timeout").(string); v != "" {
+				timeout, _ := time.ParseDuration(v)
+				opts.deadline = time.Now().Add(timeout)
+			}
+			blockClient := retryingArmStorageBlockBlobClient{blobClient, maxRetries}
+			contentMD5, err := uploadArmStorageBlobSource(blockClient, cont, name, source, d.Get("decompress").(bool), opts)
+			if err != nil {
+				return err
+			}
+
+			// Azure only stores the Content-MD5 of a blob put in a single
+			// operation, and committing a block list can't set properties, so
+			// the Content-MD5 and content type are set once the blocks are
+			// committed
+			headers["x-ms-blob-content-md5"] = contentMD5
+			if err := setArmStorageBlobProperties(blobClient, cont, name, headers); err != nil {
+				return fmt.Errorf("Error setting properties: %s", err)
+			}
+			return nil
+		}
+		// Azure checks the content against a Content-MD5 sent with it, and
+		// stores it as the blob's
+		if v := d.Get("expected_md5").(string); v != "" {
+			headers["Content-MD5"] = v
+		}
+		return retryArmStorageBlobOperation(maxRetries, fmt.Sprintf("upload of storage blob %q", name), func() error {
+			return blobClient.CreateBlockBlobFromReader(cont, name, uint64(len(content)), bytes.NewReader(content), headers)
+		})
+	case "page":
+		size := int64(d.Get("size").(int))
+		if v := d.Get("sequence_number").(int); v != 0 {
+			headers["x-ms-blob-sequence-number"] = strconv.Itoa(v)
+		}
+		err := retryArmStorageBlobOperation(maxRetries, fmt.Sprintf("creation of storage blob %q", name), func() error {
+			return blobClient.PutPageBlob(cont, name, size, headers)
+		})
+		if err != nil {
+			return err
+		}
+		if d.Get("sequence_number_action").(string) == "increment" {
+			if err := setArmStorageBlobSequenceNumber(blobClient, cont, name, "increment", 0); err != nil {
+				return fmt.Errorf("Error incrementing sequence number: %s", err)
+			}
+		}
+
+		pageClient := retryingArmStoragePageBlobClient{blobClient, maxRetries}
+		if source != "" {
+			return uploadArmStorageBlobPageSource(pageClient, cont, name, source, d.Get("decompress").(bool), size)
+		}
+		if len(content) > 0 {
+			return uploadArmStorageBlobPages(pageClient, cont, name, bytes.NewReader(content), size)
+		}
+	}
+
+	return nil
+}
+
+func resourceArmStorageBlobRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, key, err := armClient.getBlobStorageClientAndKeyForStorageAccount(resourceGroupName, storageAccountName)
+	if err != nil {
+		return err
+	}
+
 	exists, err := resourceArmStorageBlobExists(d, meta)
 	if err != nil {
 		return err
@@ -141,9 +2348,259 @@ func resourceArmStorageBlobRead(d *schema.ResourceData, meta interface{}) error
 	}
 	d.Set("url", url)
 
+	sas, err := expandArmStorageBlobSAS(d)
+	if err != nil {
+		return fmt.Errorf("Error building SAS for storage blob %q: %s", name, err)
+	}
+	sasURL := ""
+	if sas != nil && url != "" {
+		// The SAS is signed with the key the blob client was built with, so
+		// that both are replaced together once the key is rotated
+		sasURL, err = signArmStorageBlobSAS(sas, storageAccountName, key, url)
+		if err != nil {
+			return fmt.Errorf("Error building SAS for storage blob %q: %s", name, err)
+		}
+	}
+	d.Set("sas_url", sasURL)
+
+	// The vendored storage SDK cannot fetch a container's access policy, so
+	// public access is determined by requesting the blob anonymously. Proxies
+	// and throttling can answer that request with anything, so the check is
+	// best effort and never fails the refresh
+	if url != "" {
+		isPublic, err := isArmStorageBlobPublic(url)
+		if err != nil {
+			log.Printf("[WARN] Unable to check public access to storage blob %q, leaving is_public unset: %s", name, err)
+		} else {
+			d.Set("is_public", isPublic)
+		}
+	}
+
+	maxRetries := armStorageBlobMaxRetries(d, armClient, armStorageBlobRead)
+
+	var props *storage.BlobProperties
+	err = retryArmStorageBlobOperation(maxRetries, fmt.Sprintf("read of the properties of storage blob %q", name), func() error {
+		var err error
+		props, err = blobClient.GetBlobProperties(storageContainerName, name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Error retrieving properties of storage blob %q: %s", name, err)
+	}
+	if isArmStorageBlobTampered(d, props.ContentMD5) {
+		log.Printf("[INFO] Storage blob %q was changed outside of Terraform, removing it from state so it is uploaded again", name)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("type", flattenArmStorageBlobType(props.BlobType, d.Get("type").(string)))
+	// The size of a block blob is that of its content, which isn't
+	// configured, so only the size of page blobs is read back
+	if props.BlobType == storage.BlobTypePage {
+		d.Set("size", int(props.ContentLength))
+	}
+	d.Set("sequence_number", flattenArmStorageBlobSequenceNumber(d.Get("sequence_number_action").(string), d.Get("sequence_number").(int), props.SequenceNumber))
+	d.Set("copy_source", props.CopySource)
+	d.Set("copy_id", props.CopyID)
+	d.Set("copy_status", props.CopyStatus)
+	d.Set("copy_completion_time", props.CopyCompletionTime)
+	d.Set("content_md5", props.ContentMD5)
+	// The ETag header is quoted, but the ETag listed with a container's
+	// blobs isn't, so it is kept unquoted
+	d.Set("etag", strings.Trim(props.Etag, `"`))
+	verifyArmStorageBlobContent(d, props.ContentMD5)
+	verifyArmStorageBlobSource(d, props.ContentMD5)
+
+	// GetBlobProperties does not return the Content-Type of the blob, but the
+	// properties returned when listing the container do
+	listProps, err := getArmStorageBlobListProperties(blobClient, storageContainerName, name)
+	if err != nil {
+		return err
+	}
+	if listProps != nil {
+		d.Set("content_type", listProps.ContentType)
+	}
+
+	// Nor does it return the Cache-Control or Content-Disposition, which only
+	// getting the blob's properties directly does
+	var headers http.Header
+	err = retryArmStorageBlobOperation(maxRetries, fmt.Sprintf("read of the headers of storage blob %q", name), func() error {
+		var err error
+		headers, err = getArmStorageBlobHTTPHeaders(blobClient, storageContainerName, name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Error retrieving headers of storage blob %q: %s", name, err)
+	}
+	d.Set("cache_control", headers.Get("Cache-Control"))
+	d.Set("content_disposition", headers.Get("Content-Disposition"))
+
+	var metadata map[string]string
+	err = retryArmStorageBlobOperation(maxRetries, fmt.Sprintf("read of the metadata of storage blob %q", name), func() error {
+		var err error
+		metadata, err = blobClient.GetBlobMetadata(storageContainerName, name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Error retrieving metadata of storage blob %q: %s", name, err)
+	}
+	configured := d.Get("metadata").(map[string]interface{})
+	if err := d.Set("metadata", flattenArmStorageBlobMetadata(metadata, configured)); err != nil {
+		return fmt.Errorf("Error setting metadata of storage blob %q: %s", name, err)
+	}
+
 	return nil
 }
 
+// verifyArmStorageBlobContent compares the Content-MD5 stored on the blob with
+// the MD5 of content_base64 when verify_on_read is enabled. On a mismatch the
+// content is cleared from state so that the drift shows up in the next plan.
+func verifyArmStorageBlobContent(d *schema.ResourceData, contentMD5 string) {
+	if !d.Get("verify_on_read").(bool) {
+		return
+	}
+
+	v, ok := d.GetOk("content_base64")
+	if !ok {
+		return
+	}
+
+	name := d.Get("name").(string)
+	if contentMD5 == "" {
+		log.Printf("[WARN] Storage blob %q has no stored Content-MD5, unable to verify its content", name)
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(v.(string))
+	if err != nil {
+		log.Printf("[WARN] Unable to decode content_base64 of storage blob %q: %s", name, err)
+		return
+	}
+
+	sum := md5.Sum(content)
+	if expected := base64.StdEncoding.EncodeToString(sum[:]); expected != contentMD5 {
+		log.Printf("[WARN] Storage blob %q has Content-MD5 %q, expected %q: content has changed", name, contentMD5, expected)
+		d.Set("content_base64", "")
+	}
+}
+
+// isArmStorageBlobTampered reports whether the Content-MD5 of a blob with
+// verify_source enabled differs from the content_md5 recorded when it was
+// last read, meaning that something other than Terraform has changed it.
+// Nothing is recorded straight after an upload, so the first read after one
+// always passes.
+func isArmStorageBlobTampered(d *schema.ResourceData, contentMD5 string) bool {
+	if !d.Get("verify_source").(bool) {
+		return false
+	}
+
+	recorded := d.Get("content_md5").(string)
+	if recorded == "" {
+		return false
+	}
+
+	if recorded != contentMD5 {
+		log.Printf("[WARN] Storage blob %q has Content-MD5 %q, expected %q", d.Get("name").(string), contentMD5, recorded)
+		return true
+	}
+	return false
+}
+
+// verifyArmStorageBlobSource compares the Content-MD5 stored on a blob
+// uploaded from source with the MD5 of the source file. When the file has
+// changed, source is cleared from state so that the next plan uploads it
+// again, even though its path is the same.
+func verifyArmStorageBlobSource(d *schema.ResourceData, contentMD5 string) {
+	source := d.Get("source").(string)
+	if source == "" || strings.ToLower(d.Get("type").(string)) != "blob" || d.Get("write_once").(bool) {
+		return
+	}
+
+	name := d.Get("name").(string)
+	if contentMD5 == "" {
+		log.Printf("[WARN] Storage blob %q has no stored Content-MD5, unable to compare it with source", name)
+		return
+	}
+
+	expected, err := armStorageBlobSourceMD5(source, d.Get("decompress").(bool))
+	if err != nil {
+		log.Printf("[WARN] Unable to compare storage blob %q with its source: %s", name, err)
+		return
+	}
+
+	if expected != contentMD5 {
+		log.Printf("[INFO] Storage blob %q has Content-MD5 %q, but source %q has %q: source has changed", name, contentMD5, source, expected)
+		d.Set("source", "")
+	}
+}
+
+// flattenArmStorageBlobType returns the type argument matching the type of a
+// blob reported by Azure. The configured type is kept when it matches, as
+// types are compared without regard to case.
+func flattenArmStorageBlobType(blobType storage.BlobType, configured string) string {
+	var value string
+	switch blobType {
+	case storage.BlobTypeBlock:
+		value = "blob"
+	case storage.BlobTypePage:
+		value = "page"
+	case "AppendBlob":
+		value = "append"
+	default:
+		value = strings.ToLower(string(blobType))
+	}
+
+	if strings.EqualFold(value, configured) {
+		return configured
+	}
+	return value
+}
+
+// isArmStorageBlobPublic reports whether the blob at url can be read without
+// credentials, which is the case when its container allows public access.
+func isArmStorageBlobPublic(url string) (bool, error) {
+	resp, err := armStorageBlobHTTPClient.Head(url)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusForbidden, http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %q from anonymous request", resp.Status)
+	}
+}
+
+// getArmStorageBlobListProperties returns the properties of the named blob as
+// reported by listing its container, or nil if the blob is not found.
+func getArmStorageBlobListProperties(blobClient *storage.BlobStorageClient, container, name string) (*storage.BlobProperties, error) {
+	params := storage.ListBlobsParameters{
+		Prefix: name,
+	}
+
+	for {
+		resp, err := blobClient.ListBlobs(container, params)
+		if err != nil {
+			return nil, fmt.Errorf("Error listing storage blobs in container %q: %s", container, err)
+		}
+
+		for _, blob := range resp.Blobs {
+			if blob.Name == name {
+				return &blob.Properties, nil
+			}
+		}
+
+		if resp.NextMarker == "" {
+			return nil, nil
+		}
+		params.Marker = resp.NextMarker
+	}
+}
+
 func resourceArmStorageBlobExists(d *schema.ResourceData, meta interface{}) (bool, error) {
 	armClient := meta.(*ArmClient)
 
@@ -159,7 +2616,12 @@ func resourceArmStorageBlobExists(d *schema.ResourceData, meta interface{}) (boo
 	storageContainerName := d.Get("storage_container_name").(string)
 
 	log.Printf("[INFO] Checking for existence of storage blob %q.", name)
-	exists, err := blobClient.BlobExists(storageContainerName, name)
+	var exists bool
+	err = retryArmStorageBlobOperation(armStorageBlobMaxRetries(d, armClient, armStorageBlobRead), fmt.Sprintf("existence check of storage blob %q", name), func() error {
+		var err error
+		exists, err = blobClient.BlobExists(storageContainerName, name)
+		return err
+	})
 	if err != nil {
 		return false, fmt.Errorf("error testing existence of storage blob %q: %s", name, err)
 	}
@@ -186,8 +2648,27 @@ func resourceArmStorageBlobDelete(d *schema.ResourceData, meta interface{}) erro
 	name := d.Get("name").(string)
 	storageContainerName := d.Get("storage_container_name").(string)
 
-	log.Printf("[INFO] Deleting storage blob %q", name)
-	if _, err = blobClient.DeleteBlobIfExists(storageContainerName, name); err != nil {
+	if window := d.Get("prevent_destroy_if_recently_modified").(string); window != "" && !d.Get("force_destroy").(bool) {
+		// The window was validated when it was configured
+		duration, _ := time.ParseDuration(window)
+		maxRetries := armStorageBlobMaxRetries(d, armClient, armStorageBlobRead)
+		if err := checkArmStorageBlobRecentlyModified(blobClient, storageContainerName, name, duration, time.Now(), maxRetries); err != nil {
+			return err
+		}
+	}
+
+	deleteSnapshots := d.Get("delete_snapshots").(string)
+
+	log.Printf("[INFO] Deleting storage blob %q (delete_snapshots: %s)", name, deleteSnapshots)
+	err = retryArmStorageBlobOperation(armStorageBlobMaxRetries(d, armClient, armStorageBlobDelete), fmt.Sprintf("deletion of storage blob %q", name), func() error {
+		return deleteArmStorageBlob(blobClient, storageContainerName, name, deleteSnapshots)
+	})
+	if err != nil {
+		// The service's error is returned as is, so that its code (such as
+		// LeaseIdMissing or SnapshotsPresent) and request ID aren't lost
+		if serviceErr, ok := err.(storage.AzureStorageServiceError); ok {
+			return serviceErr
+		}
 		return fmt.Errorf("Error deleting storage blob %q: %s", name, err)
 	}
 
@@ -0,0 +1,14 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package azurerm
+
+import (
+	"fmt"
+	"io"
+)
+
+// mmapArmStorageBlobSource always fails on platforms without mmap, so that
+// sources are read instead.
+func mmapArmStorageBlobSource(path string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("memory mapping is not supported on this platform")
+}
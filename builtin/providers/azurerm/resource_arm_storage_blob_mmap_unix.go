@@ -0,0 +1,56 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package azurerm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmapArmStorageBlobSource memory maps the file at path for reading. Only
+// regular, non-empty files can be mapped, so callers should fall back to
+// reading the file when it returns an error.
+func mmapArmStorageBlobSource(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping outlives the file descriptor
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file")
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("file is too large to map")
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	return &armStorageBlobMmapSource{Reader: bytes.NewReader(data), data: data}, nil
+}
+
+// armStorageBlobMmapSource reads a memory mapped file, and unmaps it when
+// closed.
+type armStorageBlobMmapSource struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (s *armStorageBlobMmapSource) Close() error {
+	return syscall.Munmap(s.data)
+}
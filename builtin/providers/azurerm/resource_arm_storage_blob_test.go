@@ -1,11 +1,33 @@
 package azurerm
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"strings"
 
+	armStorage "github.com/Azure/azure-sdk-for-go/arm/storage"
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
@@ -47,6 +69,53 @@ func TestResourceAzureRMStorageBlobType_validation(t *testing.T) {
 	}
 }
 
+func TestResourceAzureRMStorageBlobType_flatten(t *testing.T) {
+	cases := []struct {
+		BlobType   storage.BlobType
+		Configured string
+		Expected   string
+	}{
+		{BlobType: storage.BlobTypeBlock, Configured: "blob", Expected: "blob"},
+		{BlobType: storage.BlobTypePage, Configured: "page", Expected: "page"},
+		{BlobType: storage.BlobTypePage, Configured: "PAGE", Expected: "PAGE"},
+		// Blobs changed or replaced outside of Terraform
+		{BlobType: storage.BlobTypePage, Configured: "blob", Expected: "page"},
+		{BlobType: storage.BlobTypeBlock, Configured: "", Expected: "blob"},
+		{BlobType: "AppendBlob", Configured: "blob", Expected: "append"},
+	}
+
+	for i, tc := range cases {
+		if value := flattenArmStorageBlobType(tc.BlobType, tc.Configured); value != tc.Expected {
+			t.Fatalf("%d: expected type %q, got %q", i, tc.Expected, value)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobAccountType_validation(t *testing.T) {
+	cases := []struct {
+		BlobType    string
+		AccountType armStorage.AccountType
+		ExpectErr   bool
+	}{
+		{BlobType: "blob", AccountType: armStorage.StandardLRS, ExpectErr: false},
+		{BlobType: "page", AccountType: armStorage.StandardGRS, ExpectErr: false},
+		{BlobType: "page", AccountType: armStorage.PremiumLRS, ExpectErr: false},
+		{BlobType: "blob", AccountType: armStorage.PremiumLRS, ExpectErr: true},
+		{BlobType: "BLOB", AccountType: armStorage.StandardZRS, ExpectErr: false},
+		{BlobType: "page", AccountType: armStorage.StandardZRS, ExpectErr: true},
+	}
+
+	for i, tc := range cases {
+		err := validateArmStorageBlobAccountType(tc.BlobType, tc.AccountType)
+		if tc.ExpectErr && err == nil {
+			t.Fatalf("%d: expected an error for a %s blob in a %s account, got none", i, tc.BlobType, tc.AccountType)
+		}
+		if !tc.ExpectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
 func TestResourceAzureRMStorageBlobSize_validation(t *testing.T) {
 	cases := []struct {
 		Value    int
@@ -72,6 +141,22 @@ func TestResourceAzureRMStorageBlobSize_validation(t *testing.T) {
 			Value:    5120,
 			ErrCount: 0,
 		},
+		{
+			Value:    0,
+			ErrCount: 0,
+		},
+		{
+			Value:    513,
+			ErrCount: 1,
+		},
+		{
+			Value:    -1,
+			ErrCount: 1,
+		},
+		{
+			Value:    -512,
+			ErrCount: 1,
+		},
 	}
 
 	for _, tc := range cases {
@@ -83,126 +168,3832 @@ func TestResourceAzureRMStorageBlobSize_validation(t *testing.T) {
 	}
 }
 
-func TestAccAzureRMStorageBlob_basic(t *testing.T) {
-	ri := acctest.RandInt()
-	rs := strings.ToLower(acctest.RandString(11))
-	config := fmt.Sprintf(testAccAzureRMStorageBlob_basic, ri, rs)
+func TestResourceAzureRMStorageBlobSize_blockBlob(t *testing.T) {
+	cases := []struct {
+		Type      string
+		Size      int
+		Source    string
+		ExpectErr bool
+	}{
+		{Type: "page", Size: 5120, ExpectErr: false},
+		{Type: "page", Size: 0, ExpectErr: false},
+		{Type: "blob", Size: 0, ExpectErr: false},
+		{Type: "blob", Size: 512, ExpectErr: true},
+		{Type: "BLOB", Size: 512, Source: "example.vhd", ExpectErr: true},
+	}
 
-	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
-		Steps: []resource.TestStep{
-			resource.TestStep{
-				Config: config,
-				Check: resource.ComposeTestCheckFunc(
-					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
-				),
-			},
-		},
-	})
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("type", tc.Type)
+		d.Set("size", tc.Size)
+		d.Set("source", tc.Source)
+
+		_, err := expandArmStorageBlobContent(d)
+		if tc.ExpectErr && err == nil {
+			t.Fatalf("%d: expected an error for a %s blob of size %d, got none", i, tc.Type, tc.Size)
+		}
+		if !tc.ExpectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
 }
 
-func testCheckAzureRMStorageBlobExists(name string) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
+func TestResourceAzureRMStorageBlobSequenceNumber_blockBlob(t *testing.T) {
+	cases := []struct {
+		Type           string
+		SequenceNumber int
+		Action         string
+		ExpectErr      bool
+	}{
+		{Type: "page", SequenceNumber: 42, ExpectErr: false},
+		{Type: "page", SequenceNumber: 42, Action: "max", ExpectErr: false},
+		{Type: "page", Action: "increment", ExpectErr: false},
+		{Type: "page", SequenceNumber: 42, Action: "increment", ExpectErr: true},
+		{Type: "blob", ExpectErr: false},
+		{Type: "blob", SequenceNumber: 42, ExpectErr: true},
+		{Type: "blob", Action: "update", ExpectErr: true},
+		{Type: "BLOB", Action: "increment", ExpectErr: true},
+	}
 
-		rs, ok := s.RootModule().Resources[name]
-		if !ok {
-			return fmt.Errorf("Not found: %s", name)
-		}
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("type", tc.Type)
+		d.Set("sequence_number", tc.SequenceNumber)
+		d.Set("sequence_number_action", tc.Action)
 
-		name := rs.Primary.Attributes["name"]
-		storageAccountName := rs.Primary.Attributes["storage_account_name"]
-		storageContainerName := rs.Primary.Attributes["storage_container_name"]
-		resourceGroup, hasResourceGroup := rs.Primary.Attributes["resource_group_name"]
-		if !hasResourceGroup {
-			return fmt.Errorf("Bad: no resource group found in state for storage blob: %s", name)
+		_, err := expandArmStorageBlobContent(d)
+		if tc.ExpectErr && err == nil {
+			t.Fatalf("%d: expected an error for a %s blob with sequence number %d and action %q, got none", i, tc.Type, tc.SequenceNumber, tc.Action)
 		}
-
-		armClient := testAccProvider.Meta().(*ArmClient)
-		blobClient, err := armClient.getBlobStorageClientForStorageAccount(resourceGroup, storageAccountName)
-		if err != nil {
-			return err
+		if !tc.ExpectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
 		}
+	}
+}
 
-		exists, err := blobClient.BlobExists(storageContainerName, name)
-		if err != nil {
-			return err
-		}
+func TestResourceAzureRMStorageBlobSequenceNumber_validation(t *testing.T) {
+	cases := []struct {
+		Value    int
+		ErrCount int
+	}{
+		{
+			Value:    -1,
+			ErrCount: 1,
+		},
+		{
+			Value:    0,
+			ErrCount: 0,
+		},
+		{
+			Value:    42,
+			ErrCount: 0,
+		},
+	}
 
-		if !exists {
-			return fmt.Errorf("Bad: Storage Blob %q (storage container: %q) does not exist", name, storageContainerName)
-		}
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobSequenceNumber(tc.Value, "azurerm_storage_blob")
 
-		return nil
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the Azure RM Storage Blob sequence number to trigger a validation error")
+		}
 	}
 }
 
-func testCheckAzureRMStorageBlobDestroy(s *terraform.State) error {
-	for _, rs := range s.RootModule().Resources {
-		if rs.Type != "azurerm_storage_blob" {
-			continue
-		}
+func TestResourceAzureRMStorageBlobSequenceNumberAction_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "update", ErrCount: 0},
+		{Value: "max", ErrCount: 0},
+		{Value: "increment", ErrCount: 0},
+		{Value: "", ErrCount: 1},
+		{Value: "Max", ErrCount: 1},
+		{Value: "decrement", ErrCount: 1},
+	}
 
-		name := rs.Primary.Attributes["name"]
-		storageAccountName := rs.Primary.Attributes["storage_account_name"]
-		storageContainerName := rs.Primary.Attributes["storage_container_name"]
-		resourceGroup, hasResourceGroup := rs.Primary.Attributes["resource_group_name"]
-		if !hasResourceGroup {
-			return fmt.Errorf("Bad: no resource group found in state for storage blob: %s", name)
-		}
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobSequenceNumberAction(tc.Value, "azurerm_storage_blob")
 
-		armClient := testAccProvider.Meta().(*ArmClient)
-		blobClient, err := armClient.getBlobStorageClientForStorageAccount(resourceGroup, storageAccountName)
-		if err != nil {
-			return nil
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for sequence number action %q, got %d", tc.ErrCount, tc.Value, len(errors))
 		}
+	}
+}
 
-		exists, err := blobClient.BlobExists(storageContainerName, name)
-		if err != nil {
-			return nil
+func TestResourceAzureRMStorageBlobSequenceNumber_max(t *testing.T) {
+	headers := expandArmStorageBlobSequenceNumberHeaders("max", 42)
+	expected := map[string]string{
+		"x-ms-sequence-number-action": "max",
+		"x-ms-blob-sequence-number":   "42",
+	}
+	if !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("Expected headers %#v for the max action, got %#v", expected, headers)
+	}
+
+	// Azure keeps its own sequence number when it is the larger one, which
+	// isn't drift, but a smaller one must be raised again
+	cases := []struct {
+		Remote   int64
+		Expected int
+	}{
+		{Remote: 42, Expected: 42},
+		{Remote: 100, Expected: 42},
+		{Remote: 7, Expected: 7},
+	}
+	for _, tc := range cases {
+		if got := flattenArmStorageBlobSequenceNumber("max", 42, tc.Remote); got != tc.Expected {
+			t.Fatalf("Expected sequence number %d in state for remote %d, got %d", tc.Expected, tc.Remote, got)
 		}
+	}
+	if got := flattenArmStorageBlobSequenceNumber("update", 42, 100); got != 100 {
+		t.Fatalf("Expected sequence number 100 in state with the update action, got %d", got)
+	}
+}
 
-		if exists {
-			return fmt.Errorf("Bad: Storage Blob %q (storage container: %q) still exists", name, storageContainerName)
+func TestResourceAzureRMStorageBlobSequenceNumber_headers(t *testing.T) {
+	cases := []struct {
+		Action   string
+		Expected map[string]string
+	}{
+		{
+			Action: "",
+			Expected: map[string]string{
+				"x-ms-sequence-number-action": "update",
+				"x-ms-blob-sequence-number":   "42",
+			},
+		},
+		{
+			Action: "increment",
+			Expected: map[string]string{
+				"x-ms-sequence-number-action": "increment",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		if headers := expandArmStorageBlobSequenceNumberHeaders(tc.Action, 42); !reflect.DeepEqual(headers, tc.Expected) {
+			t.Fatalf("Expected headers %#v for action %q, got %#v", tc.Expected, tc.Action, headers)
 		}
 	}
+}
 
-	return nil
+func TestResourceAzureRMStorageBlobCustomHeaders_validation(t *testing.T) {
+	cases := []struct {
+		Value    map[string]interface{}
+		ErrCount int
+	}{
+		{
+			Value: map[string]interface{}{
+				"Cache-Control":       "max-age=3600",
+				"Content-Disposition": "attachment",
+				"content-encoding":    "gzip",
+				"Content-Language":    "en-GB",
+				"Content-Type":        "text/plain",
+			},
+			ErrCount: 0,
+		},
+		{
+			Value: map[string]interface{}{
+				"Content-Type": "text/plain",
+				"X-Custom":     "foo",
+			},
+			ErrCount: 1,
+		},
+		{
+			Value: map[string]interface{}{
+				"Expires":       "0",
+				"Last-Modified": "never",
+			},
+			ErrCount: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobCustomHeaders(tc.Value, "custom_headers")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for custom headers %#v, got %d", tc.ErrCount, tc.Value, len(errors))
+		}
+	}
 }
 
-var testAccAzureRMStorageBlob_basic = `
-resource "azurerm_resource_group" "test" {
-    name = "acctestrg-%d"
-    location = "westus"
+func TestResourceAzureRMStorageBlobCustomHeaders_expand(t *testing.T) {
+	d := resourceArmStorageBlob().TestResourceData()
+	d.Set("custom_headers", map[string]interface{}{
+		"Cache-Control":    "max-age=3600",
+		"content-type":     "text/plain",
+		"Content-Language": "en-GB",
+	})
+
+	expected := map[string]string{
+		"x-ms-blob-cache-control":    "max-age=3600",
+		"x-ms-blob-content-type":     "text/plain",
+		"x-ms-blob-content-language": "en-GB",
+	}
+
+	headers := expandArmStorageBlobCustomHeaders(d)
+	if !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("Expected headers %#v, got %#v", expected, headers)
+	}
 }
 
-resource "azurerm_storage_account" "test" {
-    name = "acctestacc%s"
-    resource_group_name = "${azurerm_resource_group.test.name}"
-    location = "westus"
-    account_type = "Standard_LRS"
+func TestResourceAzureRMStorageBlobCustomHeaders_source(t *testing.T) {
+	source := writeTestArmStorageBlobSource(t, []byte("terraform"), false)
+	defer os.Remove(source)
 
-    tags {
-        environment = "staging"
-    }
+	d := resourceArmStorageBlob().TestResourceData()
+	d.Set("type", "blob")
+	d.Set("source", source)
+	d.Set("custom_headers", map[string]interface{}{
+		"Cache-Control": "max-age=3600",
+		"Content-Type":  "text/plain",
+	})
+
+	// The headers of a block blob uploaded from source are set once its
+	// blocks are committed, so they can be set like any other blob's
+	if _, err := expandArmStorageBlobContent(d); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"x-ms-blob-cache-control": "max-age=3600",
+		"x-ms-blob-content-type":  "text/plain",
+	}
+	if headers := expandArmStorageBlobCustomHeaders(d); !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("Expected headers %#v, got %#v", expected, headers)
+	}
 }
 
-resource "azurerm_storage_container" "test" {
-    name = "vhds"
-    resource_group_name = "${azurerm_resource_group.test.name}"
-    storage_account_name = "${azurerm_storage_account.test.name}"
-    container_access_type = "private"
+func TestResourceAzureRMStorageBlobContentType_expand(t *testing.T) {
+	cases := []struct {
+		ContentType        string
+		CacheControl       string
+		ContentDisposition string
+		CustomHeaders      map[string]interface{}
+		Expected           map[string]string
+		ExpectErr          bool
+	}{
+		{
+			ContentType: "text/html",
+			Expected: map[string]string{
+				"x-ms-blob-content-type": "text/html",
+			},
+		},
+		{
+			ContentType: "text/html",
+			CustomHeaders: map[string]interface{}{
+				"Cache-Control": "max-age=3600",
+				"content-type":  "text/html",
+			},
+			Expected: map[string]string{
+				"x-ms-blob-cache-control": "max-age=3600",
+				"x-ms-blob-content-type":  "text/html",
+			},
+		},
+		{
+			ContentType: "text/html",
+			CustomHeaders: map[string]interface{}{
+				"Content-Type": "text/plain",
+			},
+			ExpectErr: true,
+		},
+		{
+			ContentType:        "application/pdf",
+			CacheControl:       "public, max-age=86400",
+			ContentDisposition: "attachment; filename=\"report.pdf\"",
+			Expected: map[string]string{
+				"x-ms-blob-content-type":        "application/pdf",
+				"x-ms-blob-cache-control":       "public, max-age=86400",
+				"x-ms-blob-content-disposition": "attachment; filename=\"report.pdf\"",
+			},
+		},
+		{
+			CacheControl: "no-cache",
+			CustomHeaders: map[string]interface{}{
+				"Cache-Control": "max-age=3600",
+			},
+			ExpectErr: true,
+		},
+		{
+			ContentDisposition: "inline",
+			CustomHeaders: map[string]interface{}{
+				"content-disposition": "attachment",
+			},
+			ExpectErr: true,
+		},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("content_type", tc.ContentType)
+		d.Set("cache_control", tc.CacheControl)
+		d.Set("content_disposition", tc.ContentDisposition)
+		d.Set("custom_headers", tc.CustomHeaders)
+
+		err := validateArmStorageBlobHeaders(d)
+		if tc.ExpectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+
+		headers := expandArmStorageBlobCustomHeaders(d)
+		if !reflect.DeepEqual(headers, tc.Expected) {
+			t.Fatalf("%d: expected headers %#v, got %#v", i, tc.Expected, headers)
+		}
+	}
 }
 
-resource "azurerm_storage_blob" "test" {
-    name = "herpderp1.vhd"
+func TestResourceAzureRMStorageBlobCacheControl_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "no-cache", ErrCount: 0},
+		{Value: "public, max-age=3600", ErrCount: 0},
+		{Value: "private=\"Set-Cookie, X-Session\", max-age=0", ErrCount: 0},
+		{Value: "", ErrCount: 1},
+		{Value: "   ", ErrCount: 1},
+		{Value: "max-age=", ErrCount: 1},
+		{Value: "public,, max-age=3600", ErrCount: 1},
+		{Value: "max age=3600", ErrCount: 1},
+	}
 
-    resource_group_name = "${azurerm_resource_group.test.name}"
-    storage_account_name = "${azurerm_storage_account.test.name}"
-    storage_container_name = "${azurerm_storage_container.test.name}"
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobCacheControl(tc.Value, "cache_control")
 
-    type = "page"
-    size = 5120
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the Cache-Control %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobMetadata_validation(t *testing.T) {
+	cases := []struct {
+		Value    map[string]interface{}
+		ErrCount int
+	}{
+		{
+			Value: map[string]interface{}{
+				"environment": "staging",
+				"_owner":      "ops",
+				"Build2":      "42",
+			},
+			ErrCount: 0,
+		},
+		{
+			Value: map[string]interface{}{
+				"build-number": "42",
+			},
+			ErrCount: 1,
+		},
+		{
+			Value: map[string]interface{}{
+				"2fast":     "yes",
+				"has space": "yes",
+			},
+			ErrCount: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobMetadata(tc.Value, "metadata")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for metadata %#v, got %d", tc.ErrCount, tc.Value, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobMetadata_flatten(t *testing.T) {
+	remote := map[string]string{
+		"environment": "staging",
+		"buildnumber": "42",
+		"owner":       "ops",
+	}
+	configured := map[string]interface{}{
+		"Environment": "staging",
+		"BuildNumber": "41",
+	}
+
+	expected := map[string]interface{}{
+		"Environment": "staging",
+		"BuildNumber": "42",
+		"owner":       "ops",
+	}
+
+	out := flattenArmStorageBlobMetadata(remote, configured)
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Expected metadata %#v, got %#v", expected, out)
+	}
+}
+
+func TestResourceAzureRMStorageBlobContentBase64_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{
+			Value:    base64.StdEncoding.EncodeToString([]byte("hello world")),
+			ErrCount: 0,
+		},
+		{
+			Value:    "not base64!",
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobContentBase64(tc.Value, "content_base64")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for content %q, got %d", tc.ErrCount, tc.Value, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobContent_validation(t *testing.T) {
+	cases := []struct {
+		Type        string
+		Size        int
+		Content     []byte
+		ExpectError bool
+	}{
+		{
+			Type:        "page",
+			Size:        1024,
+			Content:     bytes.Repeat([]byte("a"), 512),
+			ExpectError: false,
+		},
+		{
+			Type:        "page",
+			Size:        1024,
+			Content:     bytes.Repeat([]byte("a"), 1024),
+			ExpectError: false,
+		},
+		{
+			Type:        "page",
+			Size:        1024,
+			Content:     bytes.Repeat([]byte("a"), 500),
+			ExpectError: true,
+		},
+		{
+			Type:        "page",
+			Size:        512,
+			Content:     bytes.Repeat([]byte("a"), 1024),
+			ExpectError: true,
+		},
+		{
+			Type:        "blob",
+			Size:        0,
+			Content:     []byte("hello world"),
+			ExpectError: false,
+		},
+		{
+			Type:        "blob",
+			Size:        512,
+			Content:     []byte("hello world"),
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		err := validateArmStorageBlobContent(tc.Type, tc.Size, tc.Content)
+
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error for %d bytes of %s blob content with size %d", len(tc.Content), tc.Type, tc.Size)
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Unexpected error for %d bytes of %s blob content with size %d: %s", len(tc.Content), tc.Type, tc.Size, err)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobContent_verify(t *testing.T) {
+	content := []byte("hello world")
+	encoded := base64.StdEncoding.EncodeToString(content)
+	sum := md5.Sum(content)
+	contentMD5 := base64.StdEncoding.EncodeToString(sum[:])
+	otherSum := md5.Sum([]byte("tampered"))
+	otherMD5 := base64.StdEncoding.EncodeToString(otherSum[:])
+
+	cases := []struct {
+		Verify      bool
+		ContentMD5  string
+		ExpectDrift bool
+	}{
+		{
+			Verify:      true,
+			ContentMD5:  contentMD5,
+			ExpectDrift: false,
+		},
+		{
+			Verify:      true,
+			ContentMD5:  otherMD5,
+			ExpectDrift: true,
+		},
+		{
+			Verify:      true,
+			ContentMD5:  "",
+			ExpectDrift: false,
+		},
+		{
+			Verify:      false,
+			ContentMD5:  otherMD5,
+			ExpectDrift: false,
+		},
+	}
+
+	for _, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("name", "example")
+		d.Set("content_base64", encoded)
+		d.Set("verify_on_read", tc.Verify)
+
+		verifyArmStorageBlobContent(d, tc.ContentMD5)
+
+		drift := d.Get("content_base64").(string) != encoded
+		if drift != tc.ExpectDrift {
+			t.Fatalf("Expected drift %t for verify_on_read %t and Content-MD5 %q, got %t", tc.ExpectDrift, tc.Verify, tc.ContentMD5, drift)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobName_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{
+			Value:    "a",
+			ErrCount: 0,
+		},
+		{
+			Value:    "images/2017/golden image.vhd",
+			ErrCount: 0,
+		},
+		{
+			Value:    strings.Repeat("a", 1024),
+			ErrCount: 0,
+		},
+		{
+			Value:    strings.Repeat("\u00e9", 1024),
+			ErrCount: 0,
+		},
+		{
+			Value:    "",
+			ErrCount: 1,
+		},
+		{
+			Value:    strings.Repeat("a", 1025),
+			ErrCount: 1,
+		},
+		{
+			Value:    "golden\x00.vhd",
+			ErrCount: 1,
+		},
+		{
+			Value:    "golden\n.vhd",
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobName(tc.Value, "name")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for blob name %q, got %d: %v", tc.ErrCount, tc.Value, len(errors), errors)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobContainerName_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{
+			Value:    "vhds",
+			ErrCount: 0,
+		},
+		{
+			Value:    "abc",
+			ErrCount: 0,
+		},
+		{
+			Value:    "my-container-1",
+			ErrCount: 0,
+		},
+		{
+			Value:    strings.Repeat("a", 63),
+			ErrCount: 0,
+		},
+		{
+			Value:    "$root",
+			ErrCount: 0,
+		},
+		{
+			Value:    "ab",
+			ErrCount: 1,
+		},
+		{
+			Value:    strings.Repeat("a", 64),
+			ErrCount: 1,
+		},
+		{
+			Value:    "-container",
+			ErrCount: 1,
+		},
+		{
+			Value:    "container-",
+			ErrCount: 1,
+		},
+		{
+			Value:    "my--container",
+			ErrCount: 1,
+		},
+		{
+			Value:    "MyContainer",
+			ErrCount: 1,
+		},
+		{
+			Value:    "my_container",
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobContainerName(tc.Value, "storage_container_name")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for container name %q, got %d: %v", tc.ErrCount, tc.Value, len(errors), errors)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSourceURI_validation(t *testing.T) {
+	cases := []struct {
+		Config   map[string]interface{}
+		ErrCount int
+	}{
+		{
+			Config:   map[string]interface{}{"source_uri": "https://example.blob.core.windows.net/images/golden.vhd"},
+			ErrCount: 0,
+		},
+		{
+			Config:   map[string]interface{}{"source_uri": "https://example.blob.core.windows.net/images/golden.vhd?sv=2014-02-14&sig=abc"},
+			ErrCount: 0,
+		},
+		{
+			Config:   map[string]interface{}{"source_uri": "images/golden.vhd"},
+			ErrCount: 1,
+		},
+		{
+			Config:   map[string]interface{}{"source_uri": "ftp://example.com/golden.vhd"},
+			ErrCount: 1,
+		},
+		{
+			Config:   map[string]interface{}{"source_uri": "https://example.blob.core.windows.net/images/golden.vhd", "source": "golden.vhd"},
+			ErrCount: 1,
+		},
+		{
+			Config:   map[string]interface{}{"source_uri": "https://example.blob.core.windows.net/images/golden.vhd", "content_base64": "aGVsbG8="},
+			ErrCount: 1,
+		},
+		{
+			Config:   map[string]interface{}{"source_uri": "https://example.blob.core.windows.net/images/golden.vhd", "size": 512},
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		raw := map[string]interface{}{
+			"name":                   "golden.vhd",
+			"resource_group_name":    "example",
+			"storage_account_name":   "example",
+			"storage_container_name": "example",
+			"type":                   "blob",
+		}
+		for k, v := range tc.Config {
+			raw[k] = v
+		}
+
+		rc, err := config.NewRawConfig(raw)
+		if err != nil {
+			t.Fatalf("Error creating config: %s", err)
+		}
+
+		_, errors := resourceArmStorageBlob().Validate(terraform.NewResourceConfig(rc))
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for %#v, got %d: %v", tc.ErrCount, tc.Config, len(errors), errors)
+		}
+	}
+}
+
+// testArmStorageBlobPropertiesClient returns the given properties, one per
+// call, repeating the last, or err if it is set.
+type testArmStorageBlobPropertiesClient struct {
+	props []storage.BlobProperties
+	err   error
+}
+
+func (c *testArmStorageBlobPropertiesClient) GetBlobProperties(container, name string) (*storage.BlobProperties, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	props := c.props[0]
+	if len(c.props) > 1 {
+		c.props = c.props[1:]
+	}
+	return &props, nil
+}
+
+func TestResourceAzureRMStorageBlobRecentlyModified_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "24h", ErrCount: 0},
+		{Value: "30m", ErrCount: 0},
+		{Value: "0s", ErrCount: 1},
+		{Value: "-1h", ErrCount: 1},
+		{Value: "1d", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobRecentlyModifiedWindow(tc.Value, "prevent_destroy_if_recently_modified")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the window %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobRecentlyModified_check(t *testing.T) {
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	modified := func(ago time.Duration) storage.BlobProperties {
+		return storage.BlobProperties{LastModified: now.Add(-ago).Format(http.TimeFormat)}
+	}
+
+	cases := []struct {
+		Client    *testArmStorageBlobPropertiesClient
+		ExpectErr bool
+	}{
+		// Modified within the window, so the delete is refused
+		{Client: &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{modified(5 * time.Minute)}}, ExpectErr: true},
+		{Client: &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{modified(2 * time.Hour)}}, ExpectErr: false},
+		// Errors reading the blob fail safe, refusing the delete
+		{Client: &testArmStorageBlobPropertiesClient{err: storage.UnexpectedStatusCodeError{}}, ExpectErr: true},
+		{Client: &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{{LastModified: "yesterday"}}}, ExpectErr: true},
+	}
+
+	for i, tc := range cases {
+		err := checkArmStorageBlobRecentlyModified(tc.Client, "configs", "app.json", time.Hour, now, 0)
+		if tc.ExpectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !tc.ExpectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+
+	// A blob which is already gone has nothing to protect
+	err := checkArmStorageBlobRecentlyModified(&testArmStorageBlobPropertiesClient{
+		err: storage.AzureStorageServiceError{StatusCode: http.StatusNotFound},
+	}, "configs", "app.json", time.Hour, now, 0)
+	if err != nil {
+		t.Fatalf("Expected a missing blob to be deletable, got: %s", err)
+	}
+}
+
+func TestResourceAzureRMStorageBlobDeleteSnapshots_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "none", ErrCount: 0},
+		{Value: "include", ErrCount: 0},
+		{Value: "only", ErrCount: 0},
+		{Value: "Include", ErrCount: 1},
+		{Value: "all", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobDeleteSnapshots(tc.Value, "delete_snapshots")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected delete_snapshots %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobDeleteSnapshots_request(t *testing.T) {
+	cases := []struct {
+		Status    int
+		Body      string
+		ExpectErr bool
+	}{
+		{Status: http.StatusAccepted},
+		// Already deleted
+		{Status: http.StatusNotFound},
+		{
+			Status:    http.StatusPreconditionFailed,
+			Body:      `<?xml version="1.0" encoding="utf-8"?><Error><Code>LeaseIdMissing</Code><Message>There is currently a lease on the blob and no lease ID was specified in the request.</Message></Error>`,
+			ExpectErr: true,
+		},
+		{Status: http.StatusConflict, ExpectErr: true},
+	}
+
+	for i, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "DELETE" {
+				t.Errorf("%d: expected a DELETE request, got %s", i, r.Method)
+			}
+			if got := r.Header.Get("x-ms-delete-snapshots"); got != "include" {
+				t.Errorf("%d: expected x-ms-delete-snapshots %q, got %q", i, "include", got)
+			}
+			w.Header().Set("x-ms-request-id", "request-1")
+			w.WriteHeader(tc.Status)
+			io.WriteString(w, tc.Body)
+		}))
+
+		err := deleteArmStorageBlobURI(server.URL, "include")
+		server.Close()
+
+		if !tc.ExpectErr {
+			if err != nil {
+				t.Fatalf("%d: unexpected error: %s", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if tc.Body == "" {
+			continue
+		}
+
+		serviceErr, ok := err.(storage.AzureStorageServiceError)
+		if !ok {
+			t.Fatalf("%d: expected a storage.AzureStorageServiceError, got %T: %s", i, err, err)
+		}
+		if serviceErr.Code != "LeaseIdMissing" || serviceErr.StatusCode != tc.Status || serviceErr.RequestID != "request-1" {
+			t.Fatalf("%d: unexpected service error: %#v", i, serviceErr)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobDeleteSnapshots_timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	timeout := armStorageBlobHTTPClient.Timeout
+	armStorageBlobHTTPClient.Timeout = 50 * time.Millisecond
+	defer func() { armStorageBlobHTTPClient.Timeout = timeout }()
+
+	if err := deleteArmStorageBlobURI(server.URL, "include"); err == nil {
+		t.Fatalf("Expected an error from an unresponsive endpoint")
+	}
+}
+
+func TestResourceAzureRMStorageBlobCopy_refresh(t *testing.T) {
+	cases := []struct {
+		Props     storage.BlobProperties
+		State     string
+		ExpectErr bool
+	}{
+		{Props: storage.BlobProperties{CopyID: "copy", CopyStatus: "pending"}, State: "pending"},
+		{Props: storage.BlobProperties{CopyID: "copy", CopyStatus: "success"}, State: "success"},
+		{Props: storage.BlobProperties{CopyID: "copy", CopyStatus: "failed", CopyStatusDescription: "500 InternalError"}, ExpectErr: true},
+		{Props: storage.BlobProperties{CopyID: "copy", CopyStatus: "aborted"}, ExpectErr: true},
+		{Props: storage.BlobProperties{CopyID: "other", CopyStatus: "pending"}, ExpectErr: true},
+	}
+
+	for i, tc := range cases {
+		client := &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{tc.Props}}
+		_, state, err := armStorageBlobCopyStateRefreshFunc(client, "vhds", "golden.vhd", "copy")()
+		if tc.ExpectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if state != tc.State {
+			t.Fatalf("%d: expected state %q, got %q", i, tc.State, state)
+		}
+	}
+
+	// Waiting follows a pending copy until it succeeds
+	client := &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{
+		{CopyID: "copy", CopyStatus: "pending"},
+		{CopyID: "copy", CopyStatus: "pending"},
+		{CopyID: "copy", CopyStatus: "success"},
+	}}
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"pending"},
+		Target:     []string{"success"},
+		Refresh:    armStorageBlobCopyStateRefreshFunc(client, "vhds", "golden.vhd", "copy"),
+		Timeout:    time.Minute,
+		MinTimeout: time.Millisecond,
+	}
+	if _, err := stateConf.WaitForState(); err != nil {
+		t.Fatalf("Error waiting for copy: %s", err)
+	}
+}
+
+func TestResourceAzureRMStorageBlobCopy_wait(t *testing.T) {
+	// Waiting follows a pending copy until it succeeds
+	client := &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{
+		{CopyID: "copy", CopyStatus: "pending", CopyProgress: "0/2048"},
+		{CopyID: "copy", CopyStatus: "pending", CopyProgress: "1024/2048"},
+		{CopyID: "copy", CopyStatus: "success", CopyProgress: "2048/2048"},
+	}}
+	if err := waitForArmStorageBlobCopy(client, "vhds", "golden.vhd", "copy", time.Minute, time.Millisecond); err != nil {
+		t.Fatalf("Error waiting for copy: %s", err)
+	}
+
+	// A copy which fails stops the wait
+	client = &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{
+		{CopyID: "copy", CopyStatus: "pending"},
+		{CopyID: "copy", CopyStatus: "failed", CopyStatusDescription: "500 InternalError"},
+	}}
+	if err := waitForArmStorageBlobCopy(client, "vhds", "golden.vhd", "copy", time.Minute, time.Millisecond); err == nil {
+		t.Fatalf("Expected an error for a failed copy")
+	}
+
+	// A copy which runs out of time reports how far it got
+	client = &testArmStorageBlobPropertiesClient{props: []storage.BlobProperties{
+		{CopyID: "copy", CopyStatus: "pending", CopyProgress: "1024/2048"},
+	}}
+	err := waitForArmStorageBlobCopy(client, "vhds", "golden.vhd", "copy", 5*time.Millisecond, time.Millisecond)
+	if err == nil {
+		t.Fatalf("Expected a timeout for a pending copy")
+	}
+	if !strings.Contains(err.Error(), "1024/2048 bytes copied") {
+		t.Fatalf("Expected the timeout to include the copy progress, got: %s", err)
+	}
+}
+
+func TestResourceAzureRMStorageBlobCopy_options(t *testing.T) {
+	cases := []struct {
+		Config   map[string]interface{}
+		Expected armStorageBlobCopyOptions
+	}{
+		{
+			Config:   map[string]interface{}{"wait_for_copy": true},
+			Expected: armStorageBlobCopyOptions{timeout: armStorageBlobCopyTimeout, pollInterval: armStorageBlobCopyPollInterval, wait: true},
+		},
+		{
+			Config:   map[string]interface{}{"wait_for_copy": true, "upload_timeout": "2h"},
+			Expected: armStorageBlobCopyOptions{timeout: 2 * time.Hour, pollInterval: armStorageBlobCopyPollInterval, wait: true},
+		},
+		{
+			// copy_timeout takes precedence over upload_timeout
+			Config:   map[string]interface{}{"wait_for_copy": true, "upload_timeout": "2h", "copy_timeout": "6h", "copy_poll_interval": "30s"},
+			Expected: armStorageBlobCopyOptions{timeout: 6 * time.Hour, pollInterval: 30 * time.Second, wait: true},
+		},
+		{
+			Config:   map[string]interface{}{"wait_for_copy": false},
+			Expected: armStorageBlobCopyOptions{timeout: armStorageBlobCopyTimeout, pollInterval: armStorageBlobCopyPollInterval, wait: false},
+		},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		for k, v := range tc.Config {
+			if err := d.Set(k, v); err != nil {
+				t.Fatalf("%d: error setting %s: %s", i, k, err)
+			}
+		}
+
+		if opts := expandArmStorageBlobCopyOptions(d); opts != tc.Expected {
+			t.Fatalf("%d: expected %#v, got %#v", i, tc.Expected, opts)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobCopyDuration_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "10s", ErrCount: 0},
+		{Value: "6h", ErrCount: 0},
+		{Value: "0s", ErrCount: 1},
+		{Value: "-1m", ErrCount: 1},
+		{Value: "10", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobCopyDuration(tc.Value, "copy_poll_interval")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the duration %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSAS_expand(t *testing.T) {
+	cases := []struct {
+		SAS       []interface{}
+		Expected  *armStorageBlobSAS
+		ExpectErr bool
+	}{
+		{
+			SAS:      nil,
+			Expected: nil,
+		},
+		{
+			SAS:      []interface{}{map[string]interface{}{"policy_name": "readers"}},
+			Expected: &armStorageBlobSAS{policyName: "readers"},
+		},
+		{
+			SAS:      []interface{}{map[string]interface{}{"expiry": "2030-01-01T00:00:00Z", "permissions": "r"}},
+			Expected: &armStorageBlobSAS{expiry: "2030-01-01T00:00:00Z", permissions: "r"},
+		},
+		{
+			SAS:       []interface{}{map[string]interface{}{"policy_name": "readers", "expiry": "2030-01-01T00:00:00Z"}},
+			ExpectErr: true,
+		},
+		{
+			SAS:       []interface{}{map[string]interface{}{"expiry": "2030-01-01T00:00:00Z"}},
+			ExpectErr: true,
+		},
+		{
+			SAS:       []interface{}{map[string]interface{}{}},
+			ExpectErr: true,
+		},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		if err := d.Set("sas", tc.SAS); err != nil {
+			t.Fatalf("%d: error setting sas: %s", i, err)
+		}
+
+		sas, err := expandArmStorageBlobSAS(d)
+		if tc.ExpectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if !reflect.DeepEqual(sas, tc.Expected) {
+			t.Fatalf("%d: expected %#v, got %#v", i, tc.Expected, sas)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSASExpiry_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "2030-01-01T00:00:00Z", ErrCount: 0},
+		{Value: "24h", ErrCount: 0},
+		{Value: "90m", ErrCount: 0},
+		{Value: "0s", ErrCount: 1},
+		{Value: "-1h", ErrCount: 1},
+		{Value: "2030-01-01", ErrCount: 1},
+		{Value: "tomorrow", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobSASExpiry(tc.Value, "expiry")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the SAS expiry %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSASExpiry_resolve(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.FixedZone("UTC+1", 3600))
+
+	cases := []struct {
+		Expiry   string
+		Expected string
+	}{
+		{Expiry: "2031-06-01T00:00:00Z", Expected: "2031-06-01T00:00:00Z"},
+		{Expiry: "24h", Expected: "2030-01-02T11:00:00Z"},
+		{Expiry: "30m", Expected: "2030-01-01T11:30:00Z"},
+		{Expiry: "", Expected: ""},
+	}
+
+	for i, tc := range cases {
+		if expiry := resolveArmStorageBlobSASExpiry(tc.Expiry, now); expiry != tc.Expected {
+			t.Fatalf("%d: expected expiry %q, got %q", i, tc.Expected, expiry)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSAS_sign(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("not a real storage account key"))
+	client, err := storage.NewBasicClient("example", key)
+	if err != nil {
+		t.Fatalf("Error creating storage client: %s", err)
+	}
+	blobClient := client.GetBlobService()
+	blobURL := blobClient.GetBlobURL("images", "golden.vhd")
+
+	// An ad hoc signature matches the one the SDK builds
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected, err := blobClient.GetBlobSASURI("images", "golden.vhd", expiry, "r")
+	if err != nil {
+		t.Fatalf("Error building SAS URI: %s", err)
+	}
+	sasURL, err := signArmStorageBlobSAS(&armStorageBlobSAS{expiry: expiry.Format(time.RFC3339), permissions: "r"}, "example", key, blobURL)
+	if err != nil {
+		t.Fatalf("Error signing SAS: %s", err)
+	}
+	if sasURL != expected {
+		t.Fatalf("Expected SAS URL %q, got %q", expected, sasURL)
+	}
+
+	// A policy signature names the policy and leaves out what it sets
+	sasURL, err = signArmStorageBlobSAS(&armStorageBlobSAS{policyName: "readers"}, "example", key, blobURL)
+	if err != nil {
+		t.Fatalf("Error signing SAS: %s", err)
+	}
+	u, err := url.Parse(sasURL)
+	if err != nil {
+		t.Fatalf("Error parsing SAS URL %q: %s", sasURL, err)
+	}
+	query := u.Query()
+	if query.Get("si") != "readers" || query.Get("se") != "" || query.Get("sp") != "" {
+		t.Fatalf("Expected a SAS referring to the policy only, got %q", sasURL)
+	}
+
+	h := hmac.New(sha256.New, []byte("not a real storage account key"))
+	h.Write([]byte("\n\n\n/example/images/golden.vhd\nreaders\n" + storage.DefaultAPIVersion + "\n\n\n\n\n"))
+	if sig := base64.StdEncoding.EncodeToString(h.Sum(nil)); query.Get("sig") != sig {
+		t.Fatalf("Expected signature %q, got %q", sig, query.Get("sig"))
+	}
+}
+
+func TestResourceAzureRMStorageBlobSASPermissions_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "r", ErrCount: 0},
+		{Value: "rw", ErrCount: 0},
+		{Value: "rwd", ErrCount: 0},
+		{Value: "", ErrCount: 1},
+		{Value: "wr", ErrCount: 1},
+		{Value: "rl", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobSASPermissions(tc.Value, "permissions")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the permissions %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobIsPublic(t *testing.T) {
+	cases := []struct {
+		Status      int
+		Expected    bool
+		ExpectError bool
+	}{
+		{
+			Status:   http.StatusOK,
+			Expected: true,
+		},
+		{
+			Status:   http.StatusNotFound,
+			Expected: false,
+		},
+		{
+			Status:   http.StatusForbidden,
+			Expected: false,
+		},
+		{
+			Status:      http.StatusInternalServerError,
+			ExpectError: true,
+		},
+		{
+			Status:      http.StatusConflict,
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "" {
+				t.Errorf("Expected an anonymous request, got Authorization %q", r.Header.Get("Authorization"))
+			}
+			w.WriteHeader(tc.Status)
+		}))
+
+		isPublic, err := isArmStorageBlobPublic(server.URL)
+		server.Close()
+
+		if tc.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error for status %d", tc.Status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error for status %d: %s", tc.Status, err)
+		}
+		if isPublic != tc.Expected {
+			t.Fatalf("Expected is_public %t for status %d, got %t", tc.Expected, tc.Status, isPublic)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobIsPublic_timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	timeout := armStorageBlobHTTPClient.Timeout
+	armStorageBlobHTTPClient.Timeout = 50 * time.Millisecond
+	defer func() { armStorageBlobHTTPClient.Timeout = timeout }()
+
+	if _, err := isArmStorageBlobPublic(server.URL); err == nil {
+		t.Fatalf("Expected an error from an unresponsive endpoint")
+	}
+}
+
+func TestResourceAzureRMStorageBlobEmpty_conflicts(t *testing.T) {
+	cases := []struct {
+		Config   map[string]interface{}
+		ErrCount int
+	}{
+		{
+			Config:   map[string]interface{}{"empty": true},
+			ErrCount: 0,
+		},
+		{
+			Config:   map[string]interface{}{"empty": true, "size": 512},
+			ErrCount: 1,
+		},
+		{
+			Config:   map[string]interface{}{"empty": true, "content_base64": "aGVsbG8="},
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		raw := map[string]interface{}{
+			"name":                   "marker",
+			"resource_group_name":    "example",
+			"storage_account_name":   "example",
+			"storage_container_name": "example",
+			"type":                   "blob",
+		}
+		for k, v := range tc.Config {
+			raw[k] = v
+		}
+
+		rc, err := config.NewRawConfig(raw)
+		if err != nil {
+			t.Fatalf("Error creating config: %s", err)
+		}
+
+		_, errors := resourceArmStorageBlob().Validate(terraform.NewResourceConfig(rc))
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for %#v, got %d: %v", tc.ErrCount, tc.Config, len(errors), errors)
+		}
+	}
+}
+
+// testArmStorageBlockBlobClient records the blocks uploaded to it, so that the
+// content committed to a blob can be inspected without a storage account. It
+// is safe for concurrent use, and records the most blocks uploaded at once.
+type testArmStorageBlockBlobClient struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+
+	blocks    map[string][]byte
+	committed []storage.Block
+	data      map[string][]byte
+	deleted   bool
+
+	// delay slows down each PutBlock, and failAt makes the given PutBlock
+	// call, counting from 1, fail. corruptAt flips a byte of the given block
+	// as if it was corrupted in transit.
+	delay     time.Duration
+	failAt    int
+	corruptAt int
+	calls     int
+}
+
+func (c *testArmStorageBlockBlobClient) PutBlock(container, name, blockID string, chunk []byte) error {
+	return c.PutBlockWithLength(container, name, blockID, uint64(len(chunk)), bytes.NewReader(chunk), nil)
+}
+
+func (c *testArmStorageBlockBlobClient) PutBlockWithLength(container, name, blockID string, size uint64, blob io.Reader, extraHeaders map[string]string) error {
+	c.mu.Lock()
+	c.calls++
+	call := c.calls
+	c.active++
+	if c.active > c.maxActive {
+		c.maxActive = c.active
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+
+	if call == c.failAt {
+		return fmt.Errorf("simulated failure uploading block %q", blockID)
+	}
+	time.Sleep(c.delay)
+
+	chunk, err := ioutil.ReadAll(blob)
+	if err != nil {
+		return err
+	}
+	if uint64(len(chunk)) != size {
+		return fmt.Errorf("block %q is %d bytes, expected %d", blockID, len(chunk), size)
+	}
+	if call == c.corruptAt {
+		chunk[0] ^= 0xff
+	}
+	if v, ok := extraHeaders["Content-MD5"]; ok {
+		sum := md5.Sum(chunk)
+		if v != base64.StdEncoding.EncodeToString(sum[:]) {
+			return fmt.Errorf("Md5Mismatch: the MD5 of block %q does not match its Content-MD5", blockID)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.blocks == nil {
+		c.blocks = make(map[string][]byte)
+	}
+	c.blocks[blockID] = append([]byte(nil), chunk...)
+	return nil
+}
+
+func (c *testArmStorageBlockBlobClient) PutBlockList(container, name string, blocks []storage.Block) error {
+	data := make(map[string][]byte)
+	for _, b := range blocks {
+		// Like Azure, refuse a block list whose IDs differ in length
+		if len(b.ID) != len(blocks[0].ID) {
+			return fmt.Errorf("block IDs %q and %q have different lengths", blocks[0].ID, b.ID)
+		}
+		source := c.blocks
+		if b.Status == storage.BlockStatusCommitted {
+			source = c.data
+		}
+		chunk, ok := source[b.ID]
+		if !ok {
+			return fmt.Errorf("block %q was not uploaded", b.ID)
+		}
+		data[b.ID] = chunk
+	}
+	c.committed = blocks
+	c.data = data
+	return nil
+}
+
+func (c *testArmStorageBlockBlobClient) GetBlockList(container, name string, blockType storage.BlockListType) (storage.BlockListResponse, error) {
+	var list storage.BlockListResponse
+	for _, b := range c.committed {
+		list.CommittedBlocks = append(list.CommittedBlocks, storage.BlockResponse{
+			Name: b.ID,
+			Size: int64(len(c.data[b.ID])),
+		})
+	}
+	return list, nil
+}
+
+func (c *testArmStorageBlockBlobClient) DeleteBlobIfExists(container, name string) (bool, error) {
+	c.deleted = true
+	return true, nil
+}
+
+func (c *testArmStorageBlockBlobClient) content() []byte {
+	var buf bytes.Buffer
+	for _, b := range c.committed {
+		buf.Write(c.data[b.ID])
+	}
+	return buf.Bytes()
+}
+
+func writeTestArmStorageBlobSource(t *testing.T, content []byte, compress bool) string {
+	file, err := ioutil.TempFile("", "tf-storage-blob")
+	if err != nil {
+		t.Fatalf("Error creating source file: %s", err)
+	}
+	defer file.Close()
+
+	if compress {
+		w := gzip.NewWriter(file)
+		if _, err := w.Write(content); err != nil {
+			t.Fatalf("Error writing source file: %s", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Error writing source file: %s", err)
+		}
+	} else if _, err := file.Write(content); err != nil {
+		t.Fatalf("Error writing source file: %s", err)
+	}
+
+	return file.Name()
+}
+
+func TestResourceAzureRMStorageBlobSource_decompress(t *testing.T) {
+	content := bytes.Repeat([]byte("terraform "), armStorageBlobBlockSize/4)
+
+	path := writeTestArmStorageBlobSource(t, content, true)
+	defer os.Remove(path)
+
+	client := &testArmStorageBlockBlobClient{}
+	if _, err := uploadArmStorageBlobSource(client, "vhds", "example", path, true, armStorageBlobUploadOptions{}); err != nil {
+		t.Fatalf("Error uploading source: %s", err)
+	}
+
+	if len(client.committed) != 3 {
+		t.Fatalf("Expected 3 blocks to be committed, got %d", len(client.committed))
+	}
+	if !bytes.Equal(client.content(), content) {
+		t.Fatalf("Expected the blob to hold the decompressed source")
+	}
+}
+
+func TestResourceAzureRMStorageBlobSource_contentMD5(t *testing.T) {
+	content := bytes.Repeat([]byte("terraform "), armStorageBlobBlockSize/4)
+	sum := md5.Sum(content)
+	expected := base64.StdEncoding.EncodeToString(sum[:])
+
+	for _, compress := range []bool{false, true} {
+		path := writeTestArmStorageBlobSource(t, content, compress)
+		defer os.Remove(path)
+
+		client := &testArmStorageBlockBlobClient{}
+		contentMD5, err := uploadArmStorageBlobSource(client, "vhds", "example", path, compress, armStorageBlobUploadOptions{})
+		if err != nil {
+			t.Fatalf("Error uploading source: %s", err)
+		}
+		if contentMD5 != expected {
+			t.Fatalf("Expected the MD5 of the uploaded content %q, got %q", expected, contentMD5)
+		}
+
+		sourceMD5, err := armStorageBlobSourceMD5(path, compress)
+		if err != nil {
+			t.Fatalf("Error hashing source: %s", err)
+		}
+		if sourceMD5 != expected {
+			t.Fatalf("Expected the MD5 of the source %q, got %q", expected, sourceMD5)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobExpectedMD5_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "1B2M2Y8AsgTpgAmY7PhCfg==", ErrCount: 0},
+		{Value: "d41d8cd98f00b204e9800998ecf8427e", ErrCount: 1},
+		{Value: "dGVycmFmb3Jt", ErrCount: 1},
+		{Value: "not base64", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobExpectedMD5(tc.Value, "expected_md5")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the expected_md5 %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobExpectedMD5_verify(t *testing.T) {
+	content := []byte("terraform")
+	sum := md5.Sum(content)
+	correct := base64.StdEncoding.EncodeToString(sum[:])
+	sum = md5.Sum([]byte("corrupted"))
+	wrong := base64.StdEncoding.EncodeToString(sum[:])
+
+	path := writeTestArmStorageBlobSource(t, content, false)
+	defer os.Remove(path)
+
+	cases := []struct {
+		ContentBase64 string
+		Source        string
+		ExpectedMD5   string
+		ExpectErr     bool
+	}{
+		{ContentBase64: base64.StdEncoding.EncodeToString(content), ExpectedMD5: correct},
+		{ContentBase64: base64.StdEncoding.EncodeToString(content), ExpectedMD5: wrong, ExpectErr: true},
+		{Source: path, ExpectedMD5: correct},
+		{Source: path, ExpectedMD5: wrong, ExpectErr: true},
+		{Source: path},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("type", "blob")
+		d.Set("content_base64", tc.ContentBase64)
+		d.Set("source", tc.Source)
+		d.Set("expected_md5", tc.ExpectedMD5)
+
+		// The upload is refused before any request is made
+		_, err := expandArmStorageBlobContent(d)
+		if tc.ExpectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !tc.ExpectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobContentBase64_binary(t *testing.T) {
+	// Every byte value, followed by sequences which are not valid UTF-8
+	content := make([]byte, 256)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	content = append(content, 0xc3, 0x28, 0xe2, 0x82, 0xff, 0xfe, 0x00)
+
+	d := resourceArmStorageBlob().TestResourceData()
+	d.Set("type", "blob")
+	d.Set("content_base64", base64.StdEncoding.EncodeToString(content))
+
+	out, err := expandArmStorageBlobContent(d)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !bytes.Equal(out, content) {
+		t.Fatalf("Content did not round-trip:\nexpected: %x\ngot: %x", content, out)
+	}
+}
+
+func TestResourceAzureRMStorageBlobSource_mmap(t *testing.T) {
+	content := make([]byte, 5*armStorageBlobBlockSize/2)
+	for i := range content {
+		content[i] = byte(i * 7 % 251)
+	}
+
+	cases := []struct {
+		content    []byte
+		decompress bool
+	}{
+		{content: content},
+		// Compressed and empty sources can't be mapped, and are read instead
+		{content: content, decompress: true},
+		{content: []byte{}},
+	}
+
+	for i, tc := range cases {
+		path := writeTestArmStorageBlobSource(t, tc.content, tc.decompress)
+		defer os.Remove(path)
+
+		buffered := &testArmStorageBlockBlobClient{}
+		bufferedMD5, err := uploadArmStorageBlobSource(buffered, "vhds", "example", path, tc.decompress, armStorageBlobUploadOptions{parallelism: 4})
+		if err != nil {
+			t.Fatalf("%d: error uploading source: %s", i, err)
+		}
+
+		mapped := &testArmStorageBlockBlobClient{}
+		mappedMD5, err := uploadArmStorageBlobSource(mapped, "vhds", "example", path, tc.decompress, armStorageBlobUploadOptions{parallelism: 4, useMmap: true})
+		if err != nil {
+			t.Fatalf("%d: error uploading memory mapped source: %s", i, err)
+		}
+
+		if !bytes.Equal(mapped.content(), buffered.content()) || !bytes.Equal(mapped.content(), tc.content) {
+			t.Fatalf("%d: expected the memory mapped and buffered uploads to commit the source content", i)
+		}
+		if mappedMD5 != bufferedMD5 {
+			t.Fatalf("%d: expected MD5 %q, got %q", i, bufferedMD5, mappedMD5)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSource_verify(t *testing.T) {
+	content := []byte("installer v1")
+	sum := md5.Sum(content)
+	contentMD5 := base64.StdEncoding.EncodeToString(sum[:])
+
+	path := writeTestArmStorageBlobSource(t, content, false)
+	defer os.Remove(path)
+
+	cases := []struct {
+		Type        string
+		Source      string
+		WriteOnce   bool
+		ContentMD5  string
+		ExpectDrift bool
+	}{
+		{Type: "blob", Source: path, ContentMD5: contentMD5, ExpectDrift: false},
+		{Type: "blob", Source: path, ContentMD5: "changed", ExpectDrift: true},
+		{Type: "blob", Source: path, ContentMD5: "", ExpectDrift: false},
+		{Type: "blob", Source: path, WriteOnce: true, ContentMD5: "changed", ExpectDrift: false},
+		{Type: "blob", Source: path + ".missing", ContentMD5: contentMD5, ExpectDrift: false},
+		{Type: "page", Source: path, ContentMD5: "changed", ExpectDrift: false},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("name", "example")
+		d.Set("type", tc.Type)
+		d.Set("source", tc.Source)
+		d.Set("write_once", tc.WriteOnce)
+
+		verifyArmStorageBlobSource(d, tc.ContentMD5)
+
+		drift := d.Get("source").(string) != tc.Source
+		if drift != tc.ExpectDrift {
+			t.Fatalf("%d: expected drift %t, got %t", i, tc.ExpectDrift, drift)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobTampered(t *testing.T) {
+	cases := []struct {
+		VerifySource bool
+		Recorded     string
+		ContentMD5   string
+		Expected     bool
+	}{
+		{VerifySource: true, Recorded: "original", ContentMD5: "original", Expected: false},
+		{VerifySource: true, Recorded: "original", ContentMD5: "changed", Expected: true},
+		{VerifySource: true, Recorded: "original", ContentMD5: "", Expected: true},
+		{VerifySource: true, Recorded: "", ContentMD5: "changed", Expected: false},
+		{VerifySource: false, Recorded: "original", ContentMD5: "changed", Expected: false},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		d.Set("name", "example")
+		d.Set("verify_source", tc.VerifySource)
+		d.Set("content_md5", tc.Recorded)
+
+		if tampered := isArmStorageBlobTampered(d, tc.ContentMD5); tampered != tc.Expected {
+			t.Fatalf("%d: expected tampered %t, got %t", i, tc.Expected, tampered)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSource_decompressInvalid(t *testing.T) {
+	path := writeTestArmStorageBlobSource(t, []byte("not gzipped"), false)
+	defer os.Remove(path)
+
+	client := &testArmStorageBlockBlobClient{}
+	if _, err := uploadArmStorageBlobSource(client, "vhds", "example", path, true, armStorageBlobUploadOptions{}); err == nil {
+		t.Fatalf("Expected an error uploading a source which is not gzipped")
+	}
+	if len(client.blocks) != 0 {
+		t.Fatalf("Expected no blocks to be uploaded, got %d", len(client.blocks))
+	}
+}
+
+func TestResourceAzureRMStorageBlobSource_progress(t *testing.T) {
+	cases := []struct {
+		size     int
+		expected []string
+	}{
+		{
+			size: 3*armStorageBlobBlockSize + 10,
+			expected: []string{
+				fmt.Sprintf("[INFO] Uploaded %d of %d bytes", armStorageBlobBlockSize, 3*armStorageBlobBlockSize+10),
+				fmt.Sprintf("[INFO] Uploaded %d of %d bytes", 2*armStorageBlobBlockSize, 3*armStorageBlobBlockSize+10),
+				fmt.Sprintf("[INFO] Uploaded %d of %d bytes", 3*armStorageBlobBlockSize, 3*armStorageBlobBlockSize+10),
+			},
+		},
+		{
+			// Blobs smaller than the threshold log no progress
+			size:     armStorageBlobBlockSize - 1,
+			expected: nil,
+		},
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	for i, c := range cases {
+		path := writeTestArmStorageBlobSource(t, bytes.Repeat([]byte("a"), c.size), false)
+		defer os.Remove(path)
+
+		logs.Reset()
+		client := &testArmStorageBlockBlobClient{}
+		if _, err := uploadArmStorageBlobSource(client, "vhds", "example", path, false, armStorageBlobUploadOptions{progressInterval: armStorageBlobBlockSize}); err != nil {
+			t.Fatalf("%d: error uploading source: %s", i, err)
+		}
+
+		var progress []string
+		for _, line := range strings.Split(logs.String(), "\n") {
+			if idx := strings.Index(line, "[INFO] Uploaded"); idx != -1 {
+				progress = append(progress, strings.TrimSuffix(line[idx:], ` of storage blob "example"`))
+			}
+		}
+		if !reflect.DeepEqual(progress, c.expected) {
+			t.Fatalf("%d: expected progress %#v, got %#v", i, c.expected, progress)
+		}
+	}
+}
+
+// testArmStoragePageBlobClient records the ranges written to it.
+type testArmStoragePageBlobClient struct {
+	ranges [][2]int64
+	pages  map[int64][]byte
+}
+
+func (c *testArmStoragePageBlobClient) PutPage(container, name string, startByte, endByte int64, writeType storage.PageWriteType, chunk []byte) error {
+	if int64(len(chunk)) != endByte-startByte+1 {
+		return fmt.Errorf("range %d-%d doesn't match chunk of %d bytes", startByte, endByte, len(chunk))
+	}
+	if c.pages == nil {
+		c.pages = make(map[int64][]byte)
+	}
+	c.ranges = append(c.ranges, [2]int64{startByte, endByte})
+	c.pages[startByte] = append([]byte(nil), chunk...)
+	return nil
+}
+
+func TestResourceAzureRMStorageBlobPages_upload(t *testing.T) {
+	page := bytes.Repeat([]byte("p"), 512)
+	zero := make([]byte, 512)
+
+	cases := []struct {
+		content   []byte
+		size      int64
+		ranges    [][2]int64
+		expectErr bool
+	}{
+		{
+			// Zero pages between data are skipped
+			content: bytes.Join([][]byte{page, page, zero, zero, page}, nil),
+			size:    4096,
+			ranges:  [][2]int64{{0, 1023}, {2048, 2559}},
+		},
+		{
+			// A short final page is padded
+			content: append(append([]byte(nil), page...), []byte("tail")...),
+			size:    1024,
+			ranges:  [][2]int64{{0, 1023}},
+		},
+		{
+			// An all zero source writes nothing
+			content: bytes.Join([][]byte{zero, zero}, nil),
+			size:    1024,
+			ranges:  nil,
+		},
+		{
+			// Ranges are split at the largest write size
+			content: bytes.Repeat([]byte("p"), armStorageBlobPageWriteSize+512),
+			size:    int64(armStorageBlobPageWriteSize + 512),
+			ranges:  [][2]int64{{0, int64(armStorageBlobPageWriteSize - 1)}, {int64(armStorageBlobPageWriteSize), int64(armStorageBlobPageWriteSize + 511)}},
+		},
+		{
+			content:   bytes.Join([][]byte{page, page, page}, nil),
+			size:      1024,
+			expectErr: true,
+		},
+		{
+			// Padding the final page would exceed the blob size
+			content:   append(append([]byte(nil), page...), []byte("tail")...),
+			size:      516,
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		client := &testArmStoragePageBlobClient{}
+		err := uploadArmStorageBlobPages(client, "vhds", "example", bytes.NewReader(c.content), c.size)
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if !reflect.DeepEqual(client.ranges, c.ranges) {
+			t.Fatalf("%d: expected ranges %v, got %v", i, c.ranges, client.ranges)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobPages_padding(t *testing.T) {
+	client := &testArmStoragePageBlobClient{}
+	content := []byte("short")
+	if err := uploadArmStorageBlobPages(client, "vhds", "example", bytes.NewReader(content), 512); err != nil {
+		t.Fatalf("Error uploading pages: %s", err)
+	}
+
+	expected := append(append([]byte(nil), content...), make([]byte, 512-len(content))...)
+	if !bytes.Equal(client.pages[0], expected) {
+		t.Fatalf("Expected the final page to be padded with zeroes, got %q", client.pages[0])
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_sharedBuffer(t *testing.T) {
+	// Uploads reuse buffers from a shared pool, so each blob must still be
+	// committed with its own content when several are uploaded in turn.
+	for i, b := range []byte{'a', 'b', 'c'} {
+		content := bytes.Repeat([]byte{b}, armStorageBlobBlockSize+i+1)
+		client := &testArmStorageBlockBlobClient{}
+		if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, armStorageBlobUploadOptions{}); err != nil {
+			t.Fatalf("%d: error uploading blocks: %s", i, err)
+		}
+		if !bytes.Equal(client.content(), content) {
+			t.Fatalf("%d: committed content doesn't match source", i)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_deterministicIDs(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 2*armStorageBlobBlockSize+10)
+
+	var uploads [2][]storage.Block
+	for i := range uploads {
+		client := &testArmStorageBlockBlobClient{}
+		if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, armStorageBlobUploadOptions{}); err != nil {
+			t.Fatalf("%d: error uploading blocks: %s", i, err)
+		}
+		uploads[i] = client.committed
+	}
+
+	if !reflect.DeepEqual(uploads[0], uploads[1]) {
+		t.Fatalf("Expected identical block IDs, got %#v and %#v", uploads[0], uploads[1])
+	}
+
+	// Block IDs are derived from the offset of each block
+	for i, b := range uploads[0] {
+		expected := armStorageBlobBlockID(int64(i * armStorageBlobBlockSize))
+		if b.ID != expected {
+			t.Fatalf("Expected block %d to have ID %q, got %q", i, expected, b.ID)
+		}
+		if len(b.ID) != len(uploads[0][0].ID) {
+			t.Fatalf("Expected all block IDs to have the same length, got %q and %q", uploads[0][0].ID, b.ID)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_manyBlocks(t *testing.T) {
+	// Enough blocks for their index, and their offset, to cross from one to
+	// two and from two to three digits
+	blockSize := 4 * 1024
+	content := make([]byte, 120*blockSize+10)
+	for i := range content {
+		content[i] = byte(i / blockSize)
+	}
+
+	client := &testArmStorageBlockBlobClient{}
+	opts := armStorageBlobUploadOptions{blockSize: blockSize, parallelism: 8}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), int64(len(content)), opts); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+
+	if len(client.committed) != 121 {
+		t.Fatalf("Expected 121 blocks, got %d", len(client.committed))
+	}
+	if !bytes.Equal(client.content(), content) {
+		t.Fatalf("Committed content doesn't match source")
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_cleanup(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 3*armStorageBlobBlockSize)
+
+	cases := []struct {
+		client *testArmStorageBlockBlobClient
+		opts   armStorageBlobUploadOptions
+	}{
+		{
+			// The deadline passes after the first blocks are uploaded
+			client: &testArmStorageBlockBlobClient{delay: 50 * time.Millisecond},
+			opts:   armStorageBlobUploadOptions{deadline: time.Now().Add(75 * time.Millisecond)},
+		},
+		{
+			client: &testArmStorageBlockBlobClient{failAt: 2},
+		},
+	}
+
+	for i, c := range cases {
+		err := uploadArmStorageBlobBlocks(c.client, "container", "blob", bytes.NewReader(content), -1, c.opts)
+		if err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if len(c.client.blocks) == 0 {
+			t.Fatalf("%d: expected some blocks to be uploaded before the failure", i)
+		}
+		if c.client.committed == nil || len(c.client.committed) != 0 {
+			t.Fatalf("%d: expected an empty block list to be committed, got %#v", i, c.client.committed)
+		}
+		if !c.client.deleted {
+			t.Fatalf("%d: expected the partially uploaded blob to be deleted", i)
+		}
+	}
+
+	// A successful upload cleans nothing up
+	client := &testArmStorageBlockBlobClient{}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, armStorageBlobUploadOptions{}); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+	if client.deleted {
+		t.Fatalf("Expected a successful upload not to be deleted")
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_restore(t *testing.T) {
+	original := bytes.Repeat([]byte("a"), 2*armStorageBlobBlockSize)
+	replacement := bytes.Repeat([]byte("b"), 3*armStorageBlobBlockSize)
+
+	client := &testArmStorageBlockBlobClient{}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(original), -1, armStorageBlobUploadOptions{}); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+
+	// A failed replacement keeps the existing content rather than deleting it
+	client.failAt = client.calls + 3
+	opts := armStorageBlobUploadOptions{replacing: true}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(replacement), -1, opts); err == nil {
+		t.Fatalf("Expected an error, got none")
+	}
+	if client.deleted {
+		t.Fatalf("Expected the existing blob not to be deleted")
+	}
+	if !bytes.Equal(client.content(), original) {
+		t.Fatalf("Expected the original content to be restored")
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_validate(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 3*armStorageBlobBlockSize)
+	opts := armStorageBlobUploadOptions{validateBlocks: true}
+
+	client := &testArmStorageBlockBlobClient{}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+	if !bytes.Equal(client.content(), content) {
+		t.Fatalf("Committed content doesn't match source")
+	}
+
+	// A corrupted block is rejected, failing the upload
+	client = &testArmStorageBlockBlobClient{corruptAt: 2}
+	err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts)
+	if err == nil {
+		t.Fatalf("Expected an error, got none")
+	}
+	if !strings.Contains(err.Error(), "Md5Mismatch") {
+		t.Fatalf("Expected a checksum error, got: %s", err)
+	}
+
+	// Without validation the corruption goes unnoticed
+	client = &testArmStorageBlockBlobClient{corruptAt: 2}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, armStorageBlobUploadOptions{}); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+	if bytes.Equal(client.content(), content) {
+		t.Fatalf("Expected the corrupted block to be committed")
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_parallel(t *testing.T) {
+	content := make([]byte, 10*armStorageBlobBlockSize+10)
+	for i := range content {
+		content[i] = byte(i / armStorageBlobBlockSize)
+	}
+
+	cases := []struct {
+		parallelism int
+		maxActive   int
+	}{
+		{parallelism: 0, maxActive: 1},
+		{parallelism: 1, maxActive: 1},
+		{parallelism: 4, maxActive: 4},
+		{parallelism: 32, maxActive: 32},
+	}
+
+	for i, c := range cases {
+		client := &testArmStorageBlockBlobClient{delay: 50 * time.Millisecond}
+		opts := armStorageBlobUploadOptions{parallelism: c.parallelism}
+		if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts); err != nil {
+			t.Fatalf("%d: error uploading blocks: %s", i, err)
+		}
+
+		// Blocks are committed in the order of the source, whatever order
+		// they finished uploading in
+		if !bytes.Equal(client.content(), content) {
+			t.Fatalf("%d: committed content doesn't match source", i)
+		}
+		for j, b := range client.committed {
+			if expected := armStorageBlobBlockID(int64(j * armStorageBlobBlockSize)); b.ID != expected {
+				t.Fatalf("%d: expected block %d to have ID %q, got %q", i, j, expected, b.ID)
+			}
+		}
+		if client.maxActive > c.maxActive {
+			t.Fatalf("%d: expected at most %d blocks to be uploaded at once, got %d", i, c.maxActive, client.maxActive)
+		}
+		if c.maxActive > 1 && client.maxActive < 2 {
+			t.Fatalf("%d: expected blocks to be uploaded concurrently", i)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlockSize_validation(t *testing.T) {
+	cases := []struct {
+		Value    int
+		ErrCount int
+	}{
+		{Value: 4 * 1024, ErrCount: 0},
+		{Value: 1024 * 1024, ErrCount: 0},
+		{Value: armStorageBlobBlockSize, ErrCount: 0},
+		{Value: 0, ErrCount: 1},
+		{Value: 4*1024 - 1, ErrCount: 1},
+		{Value: armStorageBlobBlockSize + 1, ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobBlockSize(tc.Value, "block_size_bytes")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the block size %d to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlockSize_effective(t *testing.T) {
+	const mb = 1024 * 1024
+	cases := []struct {
+		Total     int64
+		BlockSize int
+		Expected  int
+		ExpectErr bool
+	}{
+		{Total: 10 * mb, BlockSize: 0, Expected: armStorageBlobBlockSize},
+		{Total: 10 * mb, BlockSize: mb, Expected: mb},
+		// The size of a decompressed source isn't known
+		{Total: -1, BlockSize: 64 * 1024, Expected: 64 * 1024},
+		// 50,000 blocks of 64KB hold just over 3GB
+		{Total: 50000 * 64 * 1024, BlockSize: 64 * 1024, Expected: 64 * 1024},
+		{Total: 50000*64*1024 + 1, BlockSize: 64 * 1024, Expected: 68 * 1024},
+		{Total: 10 * 1024 * mb, BlockSize: 64 * 1024, Expected: 212 * 1024},
+		{Total: 50000 * armStorageBlobBlockSize, BlockSize: 0, Expected: armStorageBlobBlockSize},
+		{Total: 50000*armStorageBlobBlockSize + 1, BlockSize: 0, ExpectErr: true},
+	}
+
+	for i, tc := range cases {
+		blockSize, err := armStorageBlobEffectiveBlockSize(tc.Total, tc.BlockSize)
+		if tc.ExpectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if blockSize != tc.Expected {
+			t.Fatalf("%d: expected a block size of %d, got %d", i, tc.Expected, blockSize)
+		}
+		if tc.Total > 0 && (tc.Total+int64(blockSize)-1)/int64(blockSize) > int64(armStorageBlobMaxBlocks) {
+			t.Fatalf("%d: a block size of %d needs more than %d blocks", i, blockSize, armStorageBlobMaxBlocks)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlockSize_upload(t *testing.T) {
+	defer func(maxBlocks int) { armStorageBlobMaxBlocks = maxBlocks }(armStorageBlobMaxBlocks)
+	armStorageBlobMaxBlocks = 8
+
+	// 20 blocks of the configured size, which is scaled up to fit in 8
+	blockSize := 4 * 1024
+	content := make([]byte, 20*blockSize)
+	for i := range content {
+		content[i] = byte(i / blockSize)
+	}
+
+	client := &testArmStorageBlockBlobClient{}
+	opts := armStorageBlobUploadOptions{blockSize: blockSize, parallelism: 4}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), int64(len(content)), opts); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+	if !bytes.Equal(client.content(), content) {
+		t.Fatalf("Committed content doesn't match source")
+	}
+	if len(client.committed) > armStorageBlobMaxBlocks {
+		t.Fatalf("Expected at most %d blocks, got %d", armStorageBlobMaxBlocks, len(client.committed))
+	}
+
+	// A source of unknown size can't be scaled up front, so it fails once it
+	// needs too many blocks
+	client = &testArmStorageBlockBlobClient{}
+	err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts)
+	if err == nil {
+		t.Fatalf("Expected an error uploading more than %d blocks", armStorageBlobMaxBlocks)
+	}
+	if len(client.committed) != 0 {
+		t.Fatalf("Expected no blocks to be committed, got %d", len(client.committed))
+	}
+}
+
+func TestResourceAzureRMStorageBlobBlocks_parallelFailure(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 20*armStorageBlobBlockSize)
+
+	client := &testArmStorageBlockBlobClient{delay: 10 * time.Millisecond, failAt: 3}
+	opts := armStorageBlobUploadOptions{parallelism: 4}
+	err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts)
+	if err == nil {
+		t.Fatalf("Expected an error, got none")
+	}
+	if !strings.Contains(err.Error(), "simulated failure") {
+		t.Fatalf("Expected the error of the failed block, got: %s", err)
+	}
+
+	// The failure stops the rest of the source from being uploaded
+	if client.calls >= 20 {
+		t.Fatalf("Expected the upload to stop after the failure, got %d blocks uploaded", client.calls)
+	}
+	if client.committed == nil || len(client.committed) != 0 {
+		t.Fatalf("Expected an empty block list to be committed, got %#v", client.committed)
+	}
+	if !client.deleted {
+		t.Fatalf("Expected the partially uploaded blob to be deleted")
+	}
+}
+
+func TestResourceAzureRMStorageBlobRetry_retryable(t *testing.T) {
+	cases := []struct {
+		Err       error
+		Retryable bool
+	}{
+		{Err: storage.AzureStorageServiceError{StatusCode: 500}, Retryable: true},
+		{Err: storage.AzureStorageServiceError{StatusCode: 503}, Retryable: true},
+		{Err: storage.AzureStorageServiceError{StatusCode: 403}, Retryable: false},
+		{Err: storage.AzureStorageServiceError{StatusCode: 404}, Retryable: false},
+		{Err: &url.Error{Op: "Put", URL: "https://example", Err: io.EOF}, Retryable: true},
+		{Err: &url.Error{Op: "Put", URL: "https://example", Err: &net.OpError{Op: "read", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}}, Retryable: true},
+		{Err: &url.Error{Op: "Put", URL: "https://example", Err: &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}}, Retryable: false},
+		{Err: fmt.Errorf("Md5Mismatch"), Retryable: false},
+	}
+
+	for i, tc := range cases {
+		if retryable := isArmStorageBlobErrorRetryable(tc.Err); retryable != tc.Retryable {
+			t.Fatalf("%d: expected retryable %t for %v, got %t", i, tc.Retryable, tc.Err, retryable)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobRetry_operation(t *testing.T) {
+	defer func(delay time.Duration) { armStorageBlobRetryDelay = delay }(armStorageBlobRetryDelay)
+	armStorageBlobRetryDelay = time.Millisecond
+
+	transient := storage.AzureStorageServiceError{StatusCode: 503}
+	cases := []struct {
+		Errors     []error
+		MaxRetries int
+		Calls      int
+		ExpectErr  bool
+	}{
+		{Errors: nil, MaxRetries: 3, Calls: 1},
+		{Errors: []error{transient, transient}, MaxRetries: 3, Calls: 3},
+		{Errors: []error{transient, transient, transient, transient}, MaxRetries: 3, Calls: 4, ExpectErr: true},
+		{Errors: []error{transient}, MaxRetries: 0, Calls: 1, ExpectErr: true},
+		// Errors which aren't transient fail fast
+		{Errors: []error{storage.AzureStorageServiceError{StatusCode: 403}}, MaxRetries: 3, Calls: 1, ExpectErr: true},
+	}
+
+	for i, tc := range cases {
+		calls := 0
+		err := retryArmStorageBlobOperation(tc.MaxRetries, "test", func() error {
+			calls++
+			if calls <= len(tc.Errors) {
+				return tc.Errors[calls-1]
+			}
+			return nil
+		})
+		if tc.ExpectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !tc.ExpectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if calls != tc.Calls {
+			t.Fatalf("%d: expected %d calls, got %d", i, tc.Calls, calls)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobRetry_budgets(t *testing.T) {
+	defer func(delay time.Duration) { armStorageBlobRetryDelay = delay }(armStorageBlobRetryDelay)
+	armStorageBlobRetryDelay = time.Millisecond
+
+	armClient := &ArmClient{
+		blobRetries: map[armStorageBlobOperation]int{
+			armStorageBlobWrite:  5,
+			armStorageBlobRead:   2,
+			armStorageBlobDelete: 0,
+		},
+	}
+
+	cases := []struct {
+		Op         armStorageBlobOperation
+		MaxRetries int
+		Expected   int
+	}{
+		{Op: armStorageBlobWrite, Expected: 5},
+		{Op: armStorageBlobRead, Expected: 2},
+		{Op: armStorageBlobDelete, Expected: 0},
+		// max_retries only overrides the budget for writes
+		{Op: armStorageBlobWrite, MaxRetries: 1, Expected: 1},
+		{Op: armStorageBlobRead, MaxRetries: 1, Expected: 2},
+		{Op: armStorageBlobDelete, MaxRetries: 1, Expected: 0},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		if tc.MaxRetries != 0 {
+			d.Set("max_retries", tc.MaxRetries)
+		}
+
+		maxRetries := armStorageBlobMaxRetries(d, armClient, tc.Op)
+		if maxRetries != tc.Expected {
+			t.Fatalf("%d: expected %d retries, got %d", i, tc.Expected, maxRetries)
+		}
+
+		calls := 0
+		err := retryArmStorageBlobOperation(maxRetries, "test", func() error {
+			calls++
+			return storage.AzureStorageServiceError{StatusCode: 503}
+		})
+		if err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if calls != tc.Expected+1 {
+			t.Fatalf("%d: expected %d calls, got %d", i, tc.Expected+1, calls)
+		}
+	}
+}
+
+// flakyArmStorageBlockBlobClient reads part of the body of each of its first
+// failures uploads, then fails them with a 503.
+type flakyArmStorageBlockBlobClient struct {
+	*testArmStorageBlockBlobClient
+	failures int
+}
+
+func (c *flakyArmStorageBlockBlobClient) PutBlockWithLength(container, name, blockID string, size uint64, blob io.Reader, extraHeaders map[string]string) error {
+	if c.failures > 0 {
+		c.failures--
+		blob.Read(make([]byte, 10))
+		return storage.AzureStorageServiceError{StatusCode: 503}
+	}
+	return c.testArmStorageBlockBlobClient.PutBlockWithLength(container, name, blockID, size, blob, extraHeaders)
+}
+
+func TestResourceAzureRMStorageBlobRetry_blocks(t *testing.T) {
+	defer func(delay time.Duration) { armStorageBlobRetryDelay = delay }(armStorageBlobRetryDelay)
+	armStorageBlobRetryDelay = time.Millisecond
+
+	content := bytes.Repeat([]byte("a"), 2*armStorageBlobBlockSize)
+	opts := armStorageBlobUploadOptions{validateBlocks: true}
+
+	// A retried block is sent again from its start
+	recorder := &testArmStorageBlockBlobClient{}
+	client := retryingArmStorageBlockBlobClient{&flakyArmStorageBlockBlobClient{recorder, 2}, 3}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts); err != nil {
+		t.Fatalf("Error uploading blocks: %s", err)
+	}
+	if !bytes.Equal(recorder.content(), content) {
+		t.Fatalf("Committed content doesn't match source")
+	}
+
+	recorder = &testArmStorageBlockBlobClient{}
+	client = retryingArmStorageBlockBlobClient{&flakyArmStorageBlockBlobClient{recorder, 5}, 3}
+	if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts); err == nil {
+		t.Fatalf("Expected an error once the retries are used up, got none")
+	}
+}
+
+func TestResourceAzureRMStorageBlobParallelism_override(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 10*armStorageBlobBlockSize)
+	armClient := &ArmClient{blobParallelism: 1}
+
+	cases := []struct {
+		Parallelism int
+		Expected    int
+	}{
+		// unset, so the provider default is used
+		{Parallelism: 0, Expected: 1},
+		{Parallelism: 4, Expected: 4},
+	}
+
+	for i, tc := range cases {
+		d := resourceArmStorageBlob().TestResourceData()
+		if tc.Parallelism != 0 {
+			d.Set("parallelism", tc.Parallelism)
+		}
+
+		parallelism := armStorageBlobParallelism(d, armClient)
+		if parallelism != tc.Expected {
+			t.Fatalf("%d: expected parallelism %d, got %d", i, tc.Expected, parallelism)
+		}
+
+		client := &testArmStorageBlockBlobClient{delay: 50 * time.Millisecond}
+		opts := armStorageBlobUploadOptions{parallelism: parallelism}
+		if err := uploadArmStorageBlobBlocks(client, "container", "blob", bytes.NewReader(content), -1, opts); err != nil {
+			t.Fatalf("%d: error uploading blocks: %s", i, err)
+		}
+		if client.maxActive > tc.Expected {
+			t.Fatalf("%d: expected at most %d blocks to be uploaded at once, got %d", i, tc.Expected, client.maxActive)
+		}
+		if tc.Expected > 1 && client.maxActive < 2 {
+			t.Fatalf("%d: expected the override to upload blocks concurrently", i)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobParallelism_validation(t *testing.T) {
+	cases := []struct {
+		Value    int
+		ErrCount int
+	}{
+		{Value: 1, ErrCount: 0},
+		{Value: 8, ErrCount: 0},
+		{Value: 64, ErrCount: 0},
+		{Value: 0, ErrCount: 1},
+		{Value: -1, ErrCount: 1},
+		{Value: 65, ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobParallelism(tc.Value, "parallelism")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the parallelism %d to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+// testArmStorageBlobReplaceClient keeps blobs in memory. Every write checks
+// that the blob being replaced holds either its old or its new content in
+// full, as a reader would see it.
+type testArmStorageBlobReplaceClient struct {
+	blobs    map[string][]byte
+	target   string
+	old, new []byte
+	observed int
+	deleted  []string
+	t        *testing.T
+}
+
+func (c *testArmStorageBlobReplaceClient) observe() {
+	c.observed++
+	if content := c.blobs[c.target]; !bytes.Equal(content, c.old) && !bytes.Equal(content, c.new) {
+		c.t.Fatalf("Observed storage blob %q in a partial state: %q", c.target, content)
+	}
+}
+
+func (c *testArmStorageBlobReplaceClient) write(name string, chunk []byte) {
+	c.blobs[name] = append(c.blobs[name], chunk...)
+	c.observe()
+}
+
+func (c *testArmStorageBlobReplaceClient) GetBlobSASURI(container, name string, expiry time.Time, permissions string) (string, error) {
+	if permissions != "r" {
+		return "", fmt.Errorf("expected a read only signature, got %q", permissions)
+	}
+	return "https://example.blob.core.windows.net/" + container + "/" + name + "?sig=test", nil
+}
+
+func (c *testArmStorageBlobReplaceClient) DeleteBlobIfExists(container, name string) (bool, error) {
+	c.deleted = append(c.deleted, name)
+	_, ok := c.blobs[name]
+	delete(c.blobs, name)
+	c.observe()
+	return ok, nil
+}
+
+func TestResourceAzureRMStorageBlobAtomicReplace(t *testing.T) {
+	old := []byte("old content")
+	replacement := []byte("the new content, written in parts")
+	prefix := "https://example.blob.core.windows.net/container/"
+
+	cases := []struct {
+		putErr  error
+		copyErr error
+		content []byte
+	}{
+		{content: replacement},
+		{putErr: fmt.Errorf("upload failed"), content: old},
+		// A failed copy removes the blob rather than leaving it partly copied
+		{copyErr: fmt.Errorf("copy failed"), content: nil},
+	}
+
+	for i, tc := range cases {
+		client := &testArmStorageBlobReplaceClient{
+			blobs:  map[string][]byte{"blob": old},
+			target: "blob",
+			old:    old,
+			new:    replacement,
+			t:      t,
+		}
+		if tc.copyErr != nil {
+			client.new = nil
+		}
+
+		var tempName string
+		put := func(name string) error {
+			tempName = name
+			if name == "blob" {
+				t.Fatalf("%d: expected the new content to be uploaded to a temporary blob", i)
+			}
+			for start := 0; start < len(replacement); start += 8 {
+				end := start + 8
+				if end > len(replacement) {
+					end = len(replacement)
+				}
+				client.write(name, replacement[start:end])
+			}
+			return tc.putErr
+		}
+		copyOver := func(sourceURI string) error {
+			if sourceURI != prefix+tempName+"?sig=test" {
+				t.Fatalf("%d: expected a copy of %q, got %q", i, tempName, sourceURI)
+			}
+			if tc.copyErr != nil {
+				delete(client.blobs, "blob")
+				client.observe()
+				return tc.copyErr
+			}
+			client.blobs["blob"] = client.blobs[tempName]
+			client.observe()
+			return nil
+		}
+
+		err := replaceArmStorageBlob(client, "container", "blob", put, copyOver)
+		if tc.putErr == nil && tc.copyErr == nil && err != nil {
+			t.Fatalf("%d: error replacing blob: %s", i, err)
+		}
+		if (tc.putErr != nil || tc.copyErr != nil) && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+
+		if !strings.HasPrefix(tempName, "blob"+armStorageBlobReplacePrefix) {
+			t.Fatalf("%d: unexpected temporary blob name %q", i, tempName)
+		}
+		if _, ok := client.blobs[tempName]; ok || len(client.deleted) != 1 || client.deleted[0] != tempName {
+			t.Fatalf("%d: expected only the temporary blob to be deleted, got %#v", i, client.deleted)
+		}
+		if !bytes.Equal(client.blobs["blob"], tc.content) {
+			t.Fatalf("%d: expected content %q, got %q", i, tc.content, client.blobs["blob"])
+		}
+		if client.observed < 3 {
+			t.Fatalf("%d: expected the blob to be observed throughout the replacement", i)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobUploadTimeout_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "30m", ErrCount: 0},
+		{Value: "1h30m", ErrCount: 0},
+		{Value: "0s", ErrCount: 1},
+		{Value: "-5m", ErrCount: 1},
+		{Value: "30", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobUploadTimeout(tc.Value, "upload_timeout")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the upload timeout %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+// discardArmStorageBlockBlobClient accepts blocks without keeping them, so
+// that benchmarks only measure the allocations made by the upload itself.
+type discardArmStorageBlockBlobClient struct{}
+
+func (discardArmStorageBlockBlobClient) PutBlock(container, name, blockID string, chunk []byte) error {
+	return nil
+}
+
+func (discardArmStorageBlockBlobClient) PutBlockWithLength(container, name, blockID string, size uint64, blob io.Reader, extraHeaders map[string]string) error {
+	return nil
+}
+
+func (discardArmStorageBlockBlobClient) PutBlockList(container, name string, blocks []storage.Block) error {
+	return nil
+}
+
+func (discardArmStorageBlockBlobClient) GetBlockList(container, name string, blockType storage.BlockListType) (storage.BlockListResponse, error) {
+	return storage.BlockListResponse{}, nil
+}
+
+func (discardArmStorageBlockBlobClient) DeleteBlobIfExists(container, name string) (bool, error) {
+	return false, nil
+}
+
+func BenchmarkResourceAzureRMStorageBlobBlocks_small(b *testing.B) {
+	content := bytes.Repeat([]byte("a"), 1024)
+	reader := bytes.NewReader(content)
+	client := discardArmStorageBlockBlobClient{}
+
+	// Each upload logs its blocks, which would dominate the benchmark
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Seek(0, 0)
+		if err := uploadArmStorageBlobBlocks(client, "container", "blob", reader, -1, armStorageBlobUploadOptions{}); err != nil {
+			b.Fatalf("Error uploading blocks: %s", err)
+		}
+	}
+}
+
+func TestAccAzureRMStorageBlob_basic(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_basic, ri, rs)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "copy_source", ""),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "copy_status", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_pageSequenceNumber(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_pageSequenceNumber, ri, rs)
+	maxConfig := fmt.Sprintf(testAccAzureRMStorageBlob_pageSequenceNumberMax, ri, rs)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "sequence_number", "42"),
+				),
+			},
+
+			resource.TestStep{
+				Config: maxConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "sequence_number", "100"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "sequence_number_action", "max"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_contentType(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	preConfig := fmt.Sprintf(testAccAzureRMStorageBlob_contentType, ri, rs, "text/plain")
+	postConfig := fmt.Sprintf(testAccAzureRMStorageBlob_contentType, ri, rs, "text/html")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: preConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_type", "text/plain"),
+				),
+			},
+
+			// The custom headers are updated in place, keeping the blob
+			resource.TestStep{
+				Config: postConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_type", "text/html"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "custom_headers.Content-Type", "text/html"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_contentTypeAndMetadata(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	preConfig := fmt.Sprintf(testAccAzureRMStorageBlob_contentTypeAndMetadata, ri, rs, "text/plain", "no-cache", "inline", "staging")
+	postConfig := fmt.Sprintf(testAccAzureRMStorageBlob_contentTypeAndMetadata, ri, rs, "text/html", "public, max-age=3600", "attachment; filename=hello.txt", "production")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: preConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_type", "text/plain"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "cache_control", "no-cache"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_disposition", "inline"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "metadata.environment", "staging"),
+				),
+			},
+
+			// All are updated in place, keeping the content
+			resource.TestStep{
+				Config: postConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", []byte("hello")),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_type", "text/html"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "cache_control", "public, max-age=3600"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_disposition", "attachment; filename=hello.txt"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "metadata.environment", "production"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_pageContentBase64(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	content := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 1024))
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_pageContentBase64, ri, rs, content)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_base64", content),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_updateContentBase64(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	before := []byte("before")
+	after := []byte("after")
+
+	preConfig := fmt.Sprintf(testAccAzureRMStorageBlob_blockContentBase64, ri, rs, base64.StdEncoding.EncodeToString(before))
+	postConfig := fmt.Sprintf(testAccAzureRMStorageBlob_blockContentBase64, ri, rs, base64.StdEncoding.EncodeToString(after))
+
+	var etag string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: preConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", before),
+					testCheckAzureRMStorageBlobETagChanged("azurerm_storage_blob.test", &etag),
+				),
+			},
+
+			resource.TestStep{
+				Config: postConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", after),
+					testCheckAzureRMStorageBlobETagChanged("azurerm_storage_blob.test", &etag),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_binaryContentBase64(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	content := make([]byte, 4096)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatalf("Error generating content: %s", err)
+	}
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_blockContentBase64, ri, rs, base64.StdEncoding.EncodeToString(content))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", content),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_isPublic(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_isPublic, ri, rs)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "is_public", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_empty(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_empty, ri, rs)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobLength("azurerm_storage_blob.test", 0),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_sourceDecompress(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	content := []byte("hello from a gzipped source")
+
+	path := writeTestArmStorageBlobSource(t, content, true)
+	defer os.Remove(path)
+
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_sourceDecompress, ri, rs, path)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", content),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_sourceChanged(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	before := []byte("installer v1")
+	after := []byte("installer v2")
+
+	path := writeTestArmStorageBlobSource(t, before, false)
+	defer os.Remove(path)
+
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_source, ri, rs, path)
+	beforeSum := md5.Sum(before)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", before),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "content_md5", base64.StdEncoding.EncodeToString(beforeSum[:])),
+				),
+			},
+
+			// Rewriting the file at the same path uploads it again
+			resource.TestStep{
+				PreConfig: func() {
+					if err := ioutil.WriteFile(path, after, 0644); err != nil {
+						t.Fatalf("Error rewriting source: %s", err)
+					}
+				},
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", after),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_verifySource(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	deployed := []byte("deployed")
+	tampered := []byte("tampered")
+
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_verifySource, ri, rs, base64.StdEncoding.EncodeToString(deployed))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", deployed),
+				),
+			},
+
+			// A blob changed outside of Terraform is uploaded again
+			resource.TestStep{
+				PreConfig: func() {
+					armClient := testAccProvider.Meta().(*ArmClient)
+					blobClient, err := armClient.getBlobStorageClientForStorageAccount(fmt.Sprintf("acctestrg-%d", ri), "acctestacc"+rs)
+					if err != nil {
+						t.Fatalf("Error building blob client: %s", err)
+					}
+					if err := blobClient.CreateBlockBlobFromReader("vhds", "herpderp1.txt", uint64(len(tampered)), bytes.NewReader(tampered), nil); err != nil {
+						t.Fatalf("Error tampering with storage blob: %s", err)
+					}
+				},
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", deployed),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_sourceURI(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	golden := []byte("golden image")
+
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_sourceURI, ri, rs, base64.StdEncoding.EncodeToString(golden))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", golden),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.test", "copy_status", "success"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_sas(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	content := []byte("shared")
+	expiry := time.Now().UTC().Add(24 * time.Hour).Format(time.RFC3339)
+
+	config := fmt.Sprintf(testAccAzureRMStorageBlob_sas, ri, rs, base64.StdEncoding.EncodeToString(content), expiry)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobSASURL("azurerm_storage_blob.test", content),
+				),
+			},
+		},
+	})
+}
+
+// testCheckAzureRMStorageBlobSASURL checks that the blob's private content can
+// be read anonymously through its sas_url.
+func testCheckAzureRMStorageBlobSASURL(name string, expected []byte) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		sasURL := rs.Primary.Attributes["sas_url"]
+		if sasURL == "" {
+			return fmt.Errorf("Bad: Storage Blob %q has no sas_url", name)
+		}
+
+		resp, err := http.Get(sasURL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		actual, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Bad: reading Storage Blob %q through its sas_url returned %q: %s", name, resp.Status, actual)
+		}
+		if !bytes.Equal(actual, expected) {
+			return fmt.Errorf("Bad: Storage Blob %q has content %q, expected %q", name, actual, expected)
+		}
+
+		return nil
+	}
+}
+
+func TestAccAzureRMStorageBlob_writeOnce(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	seeded := []byte("seeded")
+	replacement := base64.StdEncoding.EncodeToString([]byte("replacement"))
+
+	preConfig := fmt.Sprintf(testAccAzureRMStorageBlob_writeOnceSeed, ri, rs)
+	postConfig := fmt.Sprintf(testAccAzureRMStorageBlob_writeOnce, ri, rs, replacement)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: preConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.seed", seeded),
+				),
+			},
+
+			// The existing blob is adopted and its content left untouched
+			resource.TestStep{
+				Config: postConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists("azurerm_storage_blob.test"),
+					testCheckAzureRMStorageBlobContent("azurerm_storage_blob.test", seeded),
+				),
+			},
+		},
+	})
+}
+
+func testCheckAzureRMStorageBlobContent(name string, expected []byte) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		name := rs.Primary.Attributes["name"]
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		storageContainerName := rs.Primary.Attributes["storage_container_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		blobClient, err := armClient.getBlobStorageClientForStorageAccount(resourceGroup, storageAccountName)
+		if err != nil {
+			return err
+		}
+
+		reader, err := blobClient.GetBlob(storageContainerName, name)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		actual, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(actual, expected) {
+			return fmt.Errorf("Bad: Storage Blob %q has content %q, expected %q", name, actual, expected)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageBlobLength(name string, length int64) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		name := rs.Primary.Attributes["name"]
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		storageContainerName := rs.Primary.Attributes["storage_container_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		blobClient, err := armClient.getBlobStorageClientForStorageAccount(resourceGroup, storageAccountName)
+		if err != nil {
+			return err
+		}
+
+		props, err := blobClient.GetBlobProperties(storageContainerName, name)
+		if err != nil {
+			return err
+		}
+
+		if props.ContentLength != length {
+			return fmt.Errorf("Bad: Storage Blob %q has length %d, expected %d", name, props.ContentLength, length)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageBlobExists(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		name := rs.Primary.Attributes["name"]
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		storageContainerName := rs.Primary.Attributes["storage_container_name"]
+		resourceGroup, hasResourceGroup := rs.Primary.Attributes["resource_group_name"]
+		if !hasResourceGroup {
+			return fmt.Errorf("Bad: no resource group found in state for storage blob: %s", name)
+		}
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		blobClient, err := armClient.getBlobStorageClientForStorageAccount(resourceGroup, storageAccountName)
+		if err != nil {
+			return err
+		}
+
+		exists, err := blobClient.BlobExists(storageContainerName, name)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("Bad: Storage Blob %q (storage container: %q) does not exist", name, storageContainerName)
+		}
+
+		return nil
+	}
+}
+
+// testCheckAzureRMStorageBlobETagChanged checks that the etag of the blob is
+// set, unquoted, and differs from the one recorded in etag by the previous
+// step, then records it for the next.
+func testCheckAzureRMStorageBlobETagChanged(name string, etag *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		current := rs.Primary.Attributes["etag"]
+		if current == "" || strings.Contains(current, `"`) {
+			return fmt.Errorf("Bad: etag %q of storage blob %s should be set and unquoted", current, name)
+		}
+		if current == *etag {
+			return fmt.Errorf("Bad: etag %q of storage blob %s didn't change", current, name)
+		}
+
+		*etag = current
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageBlobDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "azurerm_storage_blob" {
+			continue
+		}
+
+		name := rs.Primary.Attributes["name"]
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		storageContainerName := rs.Primary.Attributes["storage_container_name"]
+		resourceGroup, hasResourceGroup := rs.Primary.Attributes["resource_group_name"]
+		if !hasResourceGroup {
+			return fmt.Errorf("Bad: no resource group found in state for storage blob: %s", name)
+		}
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		blobClient, err := armClient.getBlobStorageClientForStorageAccount(resourceGroup, storageAccountName)
+		if err != nil {
+			return nil
+		}
+
+		exists, err := blobClient.BlobExists(storageContainerName, name)
+		if err != nil {
+			return nil
+		}
+
+		if exists {
+			return fmt.Errorf("Bad: Storage Blob %q (storage container: %q) still exists", name, storageContainerName)
+		}
+	}
+
+	return nil
+}
+
+var testAccAzureRMStorageBlob_basic = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "page"
+    size = 5120
+}
+`
+
+var testAccAzureRMStorageBlob_pageSequenceNumber = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "page"
+    size = 5120
+    sequence_number = 42
+}
+`
+
+var testAccAzureRMStorageBlob_pageSequenceNumberMax = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "page"
+    size = 5120
+    sequence_number = 100
+    sequence_number_action = "max"
+}
+`
+
+var testAccAzureRMStorageBlob_contentType = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "page"
+    size = 5120
+
+    custom_headers {
+        Content-Type = "%s"
+    }
+}
+`
+
+var testAccAzureRMStorageBlob_contentTypeAndMetadata = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "aGVsbG8="
+    content_type = "%s"
+    cache_control = "%s"
+    content_disposition = "%s"
+
+    metadata {
+        environment = "%s"
+    }
+}
+`
+
+var testAccAzureRMStorageBlob_pageContentBase64 = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "page"
+    size = 5120
+    content_base64 = "%s"
+}
+`
+
+var testAccAzureRMStorageBlob_blockContentBase64 = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "%s"
+}
+`
+
+var testAccAzureRMStorageBlob_isPublic = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "public"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "blob"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "page"
+    size = 5120
+}
+`
+
+var testAccAzureRMStorageBlob_empty = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "markers"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "logs/"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    empty = true
+}
+`
+
+var testAccAzureRMStorageBlob_sourceDecompress = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "files"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "hello.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    source = "%s"
+    decompress = true
+}
+`
+
+var testAccAzureRMStorageBlob_source = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "installer.bin"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    source = "%s"
+}
+`
+
+var testAccAzureRMStorageBlob_verifySource = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "%s"
+    verify_source = true
+}
+`
+
+var testAccAzureRMStorageBlob_sourceURI = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "images" {
+    name = "images"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "blob"
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "golden" {
+    name = "golden.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.images.name}"
+
+    type = "blob"
+    content_base64 = "%s"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "promoted.vhd"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    source_uri = "${azurerm_storage_blob.golden.url}"
+}
+`
+
+var testAccAzureRMStorageBlob_sas = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "herpderp1.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "%s"
+
+    sas {
+        expiry = "%s"
+        permissions = "r"
+    }
+}
+`
+
+var testAccAzureRMStorageBlob_writeOnceSeed = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "seeds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "seed" {
+    name = "seed.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "c2VlZGVk"
+}
+`
+
+var testAccAzureRMStorageBlob_writeOnce = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestrg-%d"
+    location = "westus"
+}
+
+resource "azurerm_storage_account" "test" {
+    name = "acctestacc%s"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "westus"
+    account_type = "Standard_LRS"
+
+    tags {
+        environment = "staging"
+    }
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "seeds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "seed" {
+    name = "seed.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "c2VlZGVk"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "seed.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "blob"
+    content_base64 = "%s"
+    write_once = true
+
+    depends_on = ["azurerm_storage_blob.seed"]
 }
 `
@@ -11,10 +11,10 @@ import (
 
 func resourceArmStorageContainer() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceArmStorageContainerCreate,
-		Read:   resourceArmStorageContainerRead,
+		Create: invalidatingStorageBlobClient(resourceArmStorageContainerCreate),
+		Read:   invalidatingStorageBlobClient(resourceArmStorageContainerRead),
 		Exists: resourceArmStorageContainerExists,
-		Delete: resourceArmStorageContainerDelete,
+		Delete: invalidatingStorageBlobClient(resourceArmStorageContainerDelete),
 
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
@@ -56,7 +56,7 @@ func validateArmStorageContainerAccessType(v interface{}, k string) (ws []string
 	}
 
 	if _, ok := validTypes[value]; !ok {
-		errors = append(errors, fmt.Errorf("Storage container access type %q is invalid, must be %q, %q or %q", value, "private", "blob", "page"))
+		errors = append(errors, fmt.Errorf("Storage container access type %q is invalid, must be %q, %q or %q", value, "private", "blob", "container"))
 	}
 	return
 }
@@ -11,6 +11,28 @@ import (
 	"github.com/hashicorp/terraform/terraform"
 )
 
+func TestResourceAzureRMStorageContainerAccessType_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "private", ErrCount: 0},
+		{Value: "blob", ErrCount: 0},
+		{Value: "container", ErrCount: 0},
+		{Value: "CONTAINER", ErrCount: 0},
+		{Value: "page", ErrCount: 1},
+		{Value: "public", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageContainerAccessType(tc.Value, "container_access_type")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the container access type %q to trigger %d validation errors, got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
 func TestAccAzureRMStorageContainer_basic(t *testing.T) {
 	ri := acctest.RandInt()
 	rs := strings.ToLower(acctest.RandString(11))
@@ -1,18 +1,52 @@
 package fastly
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/schema"
 	gofastly "github.com/sethvargo/go-fastly"
 )
 
 var fastlyNoServiceFoundErr = errors.New("No matching Fastly Service found")
 
+// The force_tls option is implemented with a request setting and a response
+// header that Terraform manages on behalf of the user. These names are
+// reserved, and objects with these names are excluded when refreshing the
+// user declared blocks.
+const (
+	fastlyForceTLSRequestSettingName = "terraform-force-tls"
+	fastlyForceTLSHeaderName         = "terraform-force-tls-hsts"
+
+	// fastlyForceTLSHSTSMaxAge is the max-age, in seconds, sent in the
+	// Strict-Transport-Security header when force_tls is enabled
+	fastlyForceTLSHSTSMaxAge = 31536000
+)
+
+// The maintenance_mode option is implemented with a request condition that
+// matches all traffic and a response object served under that condition. Both
+// share a reserved name, and are excluded when refreshing user declared
+// conditions.
+const (
+	fastlyMaintenanceModeName = "terraform-maintenance-mode"
+
+	// fastlyMaintenanceModeConditionPriority runs the maintenance condition
+	// ahead of user declared conditions, which default to a priority of 10
+	fastlyMaintenanceModeConditionPriority = 1
+
+	fastlyMaintenanceModeDefaultPage = "<html><body><h1>Down for maintenance</h1><p>This site is undergoing maintenance and will be back shortly.</p></body></html>"
+)
+
 func resourceServiceV1() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceServiceV1Create,
@@ -36,6 +70,91 @@ func resourceServiceV1() *schema.Resource {
 				Computed: true,
 			},
 
+			// Cloned Version is the most recent version Terraform has built. It is
+			// the same as active_version unless activation_token has held back the
+			// activation of a new version.
+			"cloned_version": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"activation_token": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "When set, new versions are only activated when this token changes",
+			},
+
+			"stage_before_activate": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, new versions are built and staged, and only activated while activate is true",
+			},
+
+			"activate": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to activate the staged version. Only used with stage_before_activate",
+			},
+
+			"hold_activation": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, new versions are built but left inactive, and the service is read from its active version",
+			},
+
+			// The staged version is a version which has been built but not yet
+			// activated. It is empty when the latest built version is active.
+			"staged_version": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"version_comment": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "A comment stored on each new version Terraform builds",
+				ValidateFunc: validateVersionComment,
+			},
+
+			// Last Version Comment is the comment on the active version, which is
+			// the version_comment it was built with.
+			"last_version_comment": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			// Generated VCL is the complete VCL Fastly generates for the active
+			// version. It is exported for visibility only.
+			"generated_vcl": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"treat_warnings_as_errors": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, a version which validates with warnings is treated as invalid",
+			},
+
+			"collect_stats": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, a summary of the service's traffic over the last day is read into stats",
+			},
+
+			// Stats summarises the requests, errors and hit ratio of the service
+			// over the last day. It is only read when collect_stats is true, and
+			// is exported for visibility only.
+			"stats": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
 			"domain": &schema.Schema{
 				Type:     schema.TypeSet,
 				Required: true,
@@ -51,6 +170,14 @@ func resourceServiceV1() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+
+						// CNAME status reports whether the domain's DNS points at
+						// Fastly: ok, missing or incorrect. It is exported for
+						// visibility only.
+						"cname_status": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 					},
 				},
 			},
@@ -69,6 +196,12 @@ func resourceServiceV1() *schema.Resource {
 				Description: "The default hostname for the version",
 			},
 
+			"default_log_condition": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of a RESPONSE Condition applied to logging endpoints which don't set their own response_condition",
+			},
+
 			"backend": &schema.Schema{
 				Type:     schema.TypeSet,
 				Required: true,
@@ -149,6 +282,223 @@ func resourceServiceV1() *schema.Resource {
 							Default:     100,
 							Description: "The portion of traffic to send to a specific origins. Each origin receives weight/total of the traffic.",
 						},
+						"healthcheck": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+							Description: "The name of a healthcheck to associate with this Backend",
+						},
+					},
+				},
+			},
+
+			"healthcheck": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						// required fields
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A name to refer to this healthcheck",
+						},
+						"host": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Which host to check",
+						},
+						"path": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The path to check",
+						},
+						// optional fields, defaulting to the Fastly API's defaults
+						"check_interval": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     5000,
+							Description: "How often to run the healthcheck in milliseconds",
+						},
+						"expected_response": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     200,
+							Description: "The status code expected from the host",
+						},
+						"http_version": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "1.1",
+							Description: "Whether to use version 1.0 or 1.1 HTTP",
+						},
+						"initial": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     2,
+							Description: "When loading a config, the initial number of probes to be seen as OK",
+						},
+						"method": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "HEAD",
+							Description: "Which HTTP method to use",
+						},
+						"threshold": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     3,
+							Description: "How many healthchecks must succeed to be considered healthy",
+						},
+						"timeout": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     500,
+							Description: "Timeout in milliseconds",
+						},
+						"window": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     5,
+							Description: "The number of most recent healthcheck queries to keep for this healthcheck",
+						},
+					},
+				},
+			},
+
+			"condition": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A name to refer to this Condition",
+							ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+								if v.(string) == fastlyMaintenanceModeName {
+									es = append(es, fmt.Errorf(
+										"Fastly Condition name %q is reserved for use by maintenance_mode", v.(string)))
+								}
+								return
+							},
+						},
+						"statement": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The statement used to determine if the condition is met",
+						},
+						"expressions": &schema.Schema{
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "A list of expressions combined with `operator` to generate the statement, in place of `statement`",
+						},
+						"operator": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "How `expressions` are combined, either `and` or `or`. Defaults to `and`",
+							ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+								if op := v.(string); op != "and" && op != "or" {
+									es = append(es, fmt.Errorf(
+										"%q must be one of 'and' or 'or'; found: %s", k, op))
+								}
+								return
+							},
+						},
+						"type": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "Type of the condition, either `REQUEST`, `RESPONSE`, or `CACHE`",
+							ValidateFunc: validateConditionType,
+						},
+						"priority": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     10,
+							Description: "A number used to determine the order in which multiple conditions execute. Lower numbers execute first",
+						},
+						"default": &schema.Schema{
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether this is the catch-all Condition of its type, executing after all the others. Overrides `priority`",
+						},
+					},
+				},
+			},
+
+			"conditions": &schema.Schema{
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Description:  "A map of Condition names to statements, declared alongside the condition blocks",
+				ValidateFunc: validateConditionsMap,
+			},
+
+			"conditions_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "REQUEST",
+				Description:  "Type of the Conditions in the conditions map",
+				ValidateFunc: validateConditionType,
+			},
+
+			"conditions_priority": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     10,
+				Description: "Priority of the Conditions in the conditions map",
+			},
+
+			"director": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A name to refer to this Director",
+						},
+						"backends": &schema.Schema{
+							Type:        schema.TypeSet,
+							Required:    true,
+							Description: "Names of the Backends this Director balances requests across",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Set:         schema.HashString,
+						},
+						"comment": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "An optional comment about the Director",
+						},
+						"quorum": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     75,
+							Description: "Percentage of capacity that needs to be up for the Director itself to be considered up",
+						},
+						"retries": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     5,
+							Description: "How many backends to search if it fails",
+						},
+						"type": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     1,
+							Description: "Type of load balance group to use. 1: random, 3: hash, 4: client",
+							ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+								switch v.(int) {
+								case 1, 3, 4:
+								default:
+									es = append(es, fmt.Errorf(
+										"Fastly Director type must be one of 1 (random), 3 (hash) or 4 (client); found: %d", v.(int)))
+								}
+								return
+							},
+						},
 					},
 				},
 			},
@@ -158,6 +508,123 @@ func resourceServiceV1() *schema.Resource {
 				Optional: true,
 			},
 
+			"force_tls": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Redirect HTTP requests to HTTPS and send a Strict-Transport-Security header",
+			},
+
+			"maintenance_mode": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Serve the maintenance page for all requests instead of passing them to the backends",
+			},
+
+			"maintenance_page": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     fastlyMaintenanceModeDefaultPage,
+				Description: "The HTML served while maintenance_mode is enabled",
+			},
+
+			"s3logging": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     s3LoggingResource(),
+				Set:      hashS3Logging,
+			},
+
+			"gcslogging": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: loggingEndpointResource("GCS", map[string]*schema.Schema{
+					// required fields
+					"email": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						DefaultFunc: schema.EnvDefaultFunc("FASTLY_GCS_EMAIL", ""),
+						Description: "The email address of the Google Cloud Storage service account used to write the logs",
+					},
+					"bucket_name": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The name of the bucket in which to store the logs",
+					},
+					"secret_key": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						DefaultFunc: schema.EnvDefaultFunc("FASTLY_GCS_SECRET_KEY", ""),
+						Description: "The private key of the service account, in PEM format",
+					},
+					// optional fields
+					"path": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "The path to store the logs under in the bucket",
+					},
+					"period": &schema.Schema{
+						Type:         schema.TypeInt,
+						Optional:     true,
+						Default:      3600,
+						Description:  "How frequently, in seconds, the logs are written to the bucket",
+						ValidateFunc: validateLoggingPeriod,
+					},
+					"gzip_level": &schema.Schema{
+						Type:         schema.TypeInt,
+						Optional:     true,
+						Default:      0,
+						Description:  "The gzip compression level of the logs, from 0 (no compression) to 9",
+						ValidateFunc: validateLoggingGzipLevel,
+					},
+					"timestamp_format": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     "%Y-%m-%dT%H:%M:%S.000",
+						Description: "strftime specified timestamp formatting",
+					},
+				}),
+			},
+
+			"papertrail": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: loggingEndpointResource("Papertrail", map[string]*schema.Schema{
+					// required fields
+					"address": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The address of the Papertrail log destination",
+					},
+					"port": &schema.Schema{
+						Type:        schema.TypeInt,
+						Required:    true,
+						Description: "The port of the Papertrail log destination",
+					},
+				}),
+			},
+
+			"syslog": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     syslogResource(),
+				Set:      hashSyslog,
+			},
+
+			"sumologic": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: loggingEndpointResource("Sumo Logic", map[string]*schema.Schema{
+					// required fields
+					"url": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The URL of the Sumo Logic HTTP collector to send logs to",
+					},
+				}),
+			},
+
 			"gzip": &schema.Schema{
 				Type:     schema.TypeSet,
 				Optional: true,
@@ -173,7 +640,7 @@ func resourceServiceV1() *schema.Resource {
 						"content_types": &schema.Schema{
 							Type:        schema.TypeSet,
 							Optional:    true,
-							Description: "Content types to apply automatic gzip to",
+							Description: "Content types to apply automatic gzip to. A wildcard like `text/*` expands to the common compressible types of that kind",
 							Elem:        &schema.Schema{Type: schema.TypeString},
 						},
 						"extensions": &schema.Schema{
@@ -182,12 +649,24 @@ func resourceServiceV1() *schema.Resource {
 							Description: "File extensions to apply automatic gzip to. Do not include '.'",
 							Elem:        &schema.Schema{Type: schema.TypeString},
 						},
-						// These fields represent Fastly options that Terraform does not
-						// currently support
 						"cache_condition": &schema.Schema{
 							Type:        schema.TypeString,
-							Computed:    true,
-							Description: "Optional name of a CacheCondition to apply.",
+							Optional:    true,
+							Description: "Optional name of a CACHE Condition to apply.",
+						},
+					},
+				},
+			},
+
+			"dictionary": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A unique name for the Edge Dictionary, as used in table.lookup",
 						},
 					},
 				},
@@ -203,6 +682,13 @@ func resourceServiceV1() *schema.Resource {
 							Type:        schema.TypeString,
 							Required:    true,
 							Description: "A name to refer to this Header object",
+							ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+								if v.(string) == fastlyForceTLSHeaderName {
+									es = append(es, fmt.Errorf(
+										"Fastly Header name %q is reserved for use by force_tls", v.(string)))
+								}
+								return
+							},
 						},
 						"action": &schema.Schema{
 							Type:        schema.TypeString,
@@ -337,25 +823,104 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 	for _, v := range []string{
 		"domain",
 		"backend",
+		"healthcheck",
+		"condition",
+		"conditions",
+		"conditions_type",
+		"conditions_priority",
+		"director",
+		"dictionary",
 		"default_host",
 		"default_ttl",
 		"header",
 		"gzip",
+		"s3logging",
+		"gcslogging",
+		"papertrail",
+		"syslog",
+		"sumologic",
+		"default_log_condition",
+		"force_tls",
+		"maintenance_mode",
+		"maintenance_page",
 	} {
 		if d.HasChange(v) {
 			needsChange = true
 		}
 	}
 
+	// Without an activation_token every new version is activated as soon as it
+	// is built. With one, versions are built but only activated when the token
+	// changes. With stage_before_activate, versions are also held back until
+	// activate is true, and with hold_activation they are never activated.
+	activate := d.Get("activation_token").(string) == "" || d.HasChange("activation_token")
+	if d.Get("stage_before_activate").(bool) && !d.Get("activate").(bool) {
+		activate = false
+	}
+	if d.Get("hold_activation").(bool) {
+		activate = false
+	}
+
 	if needsChange {
-		latestVersion := d.Get("active_version").(string)
-		if latestVersion == "" {
-			// If the service was just created, there is an empty Version 1 available
-			// that is unlocked and can be updated
-			latestVersion = "1"
-		} else {
-			// Clone the latest version, giving us an unlocked version we can modify
-			log.Printf("[DEBUG] Creating clone of version (%s) for updates", latestVersion)
+		// Conditions referenced by other blocks must be declared in the
+		// condition set. Check this before creating a new version so an invalid
+		// reference doesn't leave behind an unused version.
+		if err := validateConditionNames(d); err != nil {
+			return err
+		}
+		if err := validateConditionStatements(d); err != nil {
+			return err
+		}
+		if err := validateDefaultConditions(d); err != nil {
+			return err
+		}
+		if err := validateGzipConditions(d); err != nil {
+			return err
+		}
+		if err := validateGzipContentTypes(d); err != nil {
+			return err
+		}
+		if err := validateDefaultLogCondition(d); err != nil {
+			return err
+		}
+		if err := validateLoggingConditions(d, "s3logging", "S3 logging"); err != nil {
+			return err
+		}
+		if err := validateLoggingConditions(d, "gcslogging", "GCS logging"); err != nil {
+			return err
+		}
+		if err := validateLoggingConditions(d, "papertrail", "Papertrail"); err != nil {
+			return err
+		}
+		if err := validateLoggingConditions(d, "syslog", "Syslog"); err != nil {
+			return err
+		}
+		if err := validateLoggingConditions(d, "sumologic", "Sumo Logic"); err != nil {
+			return err
+		}
+		if err := validateDirectorBackends(d); err != nil {
+			return err
+		}
+		if err := validateBackendHealthchecks(d); err != nil {
+			return err
+		}
+		if err := validateDictionaryReferences(d); err != nil {
+			return err
+		}
+
+		// Build on the version the state was last read from, so the diff is
+		// applied to the configuration it was computed against. That is the
+		// version held back from activation, if any, unless hold_activation was
+		// set and the state was read from the active version.
+		hold, _ := d.GetChange("hold_activation")
+		latestVersion := serviceV1ReadVersion(d.Get("active_version").(string), d.Get("cloned_version").(string), hold.(bool))
+		if latestVersion == "" {
+			// If the service was just created, there is an empty Version 1 available
+			// that is unlocked and can be updated
+			latestVersion = "1"
+		} else {
+			// Clone the latest version, giving us an unlocked version we can modify
+			log.Printf("[DEBUG] Creating clone of version (%s) for updates", latestVersion)
 			newVersion, err := conn.CloneVersion(&gofastly.CloneVersionInput{
 				Service: d.Id(),
 				Version: latestVersion,
@@ -374,6 +939,20 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 			time.Sleep(7 * time.Second)
 		}
 
+		// Comment the version, so its changes can be traced back to the apply
+		// that made them
+		if comment := d.Get("version_comment").(string); comment != "" {
+			log.Printf("[DEBUG] Commenting Fastly Service (%s), Version (%s): %s", d.Id(), latestVersion, comment)
+			_, err := conn.UpdateVersion(&gofastly.UpdateVersionInput{
+				Service: d.Id(),
+				Version: latestVersion,
+				Comment: comment,
+			})
+			if err != nil {
+				return fmt.Errorf("[ERR] Error commenting version (%s): %s", latestVersion, err)
+			}
+		}
+
 		// update general settings
 		if d.HasChange("default_host") || d.HasChange("default_ttl") {
 			opts := gofastly.UpdateSettingsInput{
@@ -395,6 +974,102 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 			}
 		}
 
+		// Conditions need to be updated first, as they can be referenced by other
+		// configuration objects (Backends, Request Headers, etc)
+
+		// Find difference in Conditions, declared in both the condition set and
+		// the conditions map
+		if d.HasChange("condition") || d.HasChange("conditions") || d.HasChange("conditions_type") || d.HasChange("conditions_priority") {
+			// Note: we don't utilize the PUT endpoint to update these objects, we simply
+			// destroy it and create a new one. This is how Terraform works with nested
+			// sub resources, we only get the full diff not a partial set item diff.
+			// Because this is done on a new version of the configuration, this is
+			// considered safe
+			oc, nc := d.GetChange("condition")
+			if oc == nil {
+				oc = new(schema.Set)
+			}
+			if nc == nil {
+				nc = new(schema.Set)
+			}
+			ocm, ncm := d.GetChange("conditions")
+			oct, nct := d.GetChange("conditions_type")
+			ocp, ncp := d.GetChange("conditions_priority")
+
+			ocs := mergeConditions(oc.(*schema.Set), ocm, oct.(string), ocp.(int))
+			ncs := mergeConditions(nc.(*schema.Set), ncm, nct.(string), ncp.(int))
+
+			removeConditions := ocs.Difference(ncs).List()
+			addConditions := ncs.Difference(ocs).List()
+
+			// DELETE old Conditions
+			for _, cRaw := range removeConditions {
+				cf := cRaw.(map[string]interface{})
+				opts := gofastly.DeleteConditionInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    cf["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Conditions Removal opts: %#v", opts)
+				err := conn.DeleteCondition(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Default Conditions execute after every other Condition of their
+			// type, so their priority depends on the whole set
+			defaultPriorities := defaultConditionPriorities(ncs)
+
+			// POST new Conditions
+			added := make(map[string]bool)
+			for _, cRaw := range addConditions {
+				cf := cRaw.(map[string]interface{})
+				statement, err := conditionStatement(cf)
+				if err != nil {
+					return err
+				}
+				opts := gofastly.CreateConditionInput{
+					Service:   d.Id(),
+					Version:   latestVersion,
+					Name:      cf["name"].(string),
+					Type:      cf["type"].(string),
+					Statement: statement,
+					Priority:  cf["priority"].(int),
+				}
+				if p, ok := defaultPriorities[opts.Name]; ok {
+					opts.Priority = p
+				}
+
+				log.Printf("[DEBUG] Create Conditions Opts: %#v", opts)
+				_, err = conn.CreateCondition(&opts)
+				if err != nil {
+					return err
+				}
+				added[opts.Name] = true
+			}
+
+			// Move unchanged default Conditions after any Condition added above
+			for name, p := range defaultPriorities {
+				if added[name] {
+					continue
+				}
+				opts := gofastly.UpdateConditionInput{
+					Service:  d.Id(),
+					Version:  latestVersion,
+					Name:     name,
+					Priority: p,
+				}
+
+				log.Printf("[DEBUG] Update Default Condition Opts: %#v", opts)
+				_, err := conn.UpdateCondition(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
 		// Find differences in domains
 		if d.HasChange("domain") {
 			// Note: we don't utilize the PUT endpoint to update a Domain, we simply
@@ -453,6 +1128,68 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 			}
 		}
 
+		// Find differences in Healthchecks. Healthchecks need to exist before any
+		// Backend which is associated with them
+		if d.HasChange("healthcheck") {
+			// Note: as with Backends, we don't utilize the PUT endpoint to update a
+			// Healthcheck, we simply destroy it and create a new one
+			oh, nh := d.GetChange("healthcheck")
+			if oh == nil {
+				oh = new(schema.Set)
+			}
+			if nh == nil {
+				nh = new(schema.Set)
+			}
+
+			ohs := oh.(*schema.Set)
+			nhs := nh.(*schema.Set)
+
+			removeHealthchecks := ohs.Difference(nhs).List()
+			addHealthchecks := nhs.Difference(ohs).List()
+
+			// DELETE old Healthchecks
+			for _, hRaw := range removeHealthchecks {
+				hf := hRaw.(map[string]interface{})
+				opts := gofastly.DeleteHealthCheckInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    hf["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Healthcheck Removal opts: %#v", opts)
+				err := conn.DeleteHealthCheck(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			// POST new Healthchecks
+			for _, hRaw := range addHealthchecks {
+				hf := hRaw.(map[string]interface{})
+				opts := gofastly.CreateHealthCheckInput{
+					Service:          d.Id(),
+					Version:          latestVersion,
+					Name:             hf["name"].(string),
+					Host:             hf["host"].(string),
+					Path:             hf["path"].(string),
+					CheckInterval:    uint(hf["check_interval"].(int)),
+					ExpectedResponse: uint(hf["expected_response"].(int)),
+					HTTPVersion:      hf["http_version"].(string),
+					Initial:          uint(hf["initial"].(int)),
+					Method:           hf["method"].(string),
+					Threshold:        uint(hf["threshold"].(int)),
+					Timeout:          uint(hf["timeout"].(int)),
+					Window:           uint(hf["window"].(int)),
+				}
+
+				log.Printf("[DEBUG] Create Healthcheck Opts: %#v", opts)
+				_, err := conn.CreateHealthCheck(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
 		// find difference in backends
 		if d.HasChange("backend") {
 			// POST new Backends
@@ -506,6 +1243,7 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 					FirstByteTimeout:    uint(df["first_byte_timeout"].(int)),
 					MaxConn:             uint(df["max_conn"].(int)),
 					Weight:              uint(df["weight"].(int)),
+					HealthCheck:         df["healthcheck"].(string),
 				}
 
 				log.Printf("[DEBUG] Create Backend Opts: %#v", opts)
@@ -516,6 +1254,173 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 			}
 		}
 
+		// Directors are reconciled by name, so that changing the Backends of an
+		// existing Director only adds or removes the changed members rather than
+		// destroying and recreating the Director
+		if d.HasChange("director") {
+			od, nd := d.GetChange("director")
+			if od == nil {
+				od = new(schema.Set)
+			}
+			if nd == nil {
+				nd = new(schema.Set)
+			}
+
+			oldDirectors := directorsByName(od.(*schema.Set))
+			newDirectors := directorsByName(nd.(*schema.Set))
+
+			// DELETE old Directors
+			for name := range oldDirectors {
+				if _, ok := newDirectors[name]; ok {
+					continue
+				}
+
+				opts := gofastly.DeleteDirectorInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    name,
+				}
+
+				log.Printf("[DEBUG] Fastly Director Removal opts: %#v", opts)
+				err := conn.DeleteDirector(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			for name, df := range newDirectors {
+				var removeMembers, addMembers []interface{}
+
+				if of, ok := oldDirectors[name]; !ok {
+					opts := gofastly.CreateDirectorInput{
+						Service: d.Id(),
+						Version: latestVersion,
+						Name:    name,
+						Comment: df["comment"].(string),
+						Quorum:  uint(df["quorum"].(int)),
+						Type:    gofastly.DirectorType(df["type"].(int)),
+						Retries: uint(df["retries"].(int)),
+					}
+
+					log.Printf("[DEBUG] Create Director Opts: %#v", opts)
+					_, err := conn.CreateDirector(&opts)
+					if err != nil {
+						return err
+					}
+
+					addMembers = df["backends"].(*schema.Set).List()
+				} else {
+					if of["comment"] != df["comment"] || of["quorum"] != df["quorum"] ||
+						of["type"] != df["type"] || of["retries"] != df["retries"] {
+						opts := gofastly.UpdateDirectorInput{
+							Service: d.Id(),
+							Version: latestVersion,
+							Name:    name,
+							Comment: df["comment"].(string),
+							Quorum:  uint(df["quorum"].(int)),
+							Type:    gofastly.DirectorType(df["type"].(int)),
+							Retries: uint(df["retries"].(int)),
+						}
+
+						log.Printf("[DEBUG] Update Director Opts: %#v", opts)
+						_, err := conn.UpdateDirector(&opts)
+						if err != nil {
+							return err
+						}
+					}
+
+					obs := of["backends"].(*schema.Set)
+					nbs := df["backends"].(*schema.Set)
+					removeMembers = obs.Difference(nbs).List()
+					addMembers = nbs.Difference(obs).List()
+				}
+
+				for _, b := range removeMembers {
+					opts := gofastly.DeleteDirectorBackendInput{
+						Service:  d.Id(),
+						Version:  latestVersion,
+						Director: name,
+						Backend:  b.(string),
+					}
+
+					log.Printf("[DEBUG] Fastly Director Backend Removal opts: %#v", opts)
+					err := conn.DeleteDirectorBackend(&opts)
+					if err != nil {
+						// The member is already gone if its Backend was removed above
+						if herr, ok := err.(*gofastly.HTTPError); ok && herr.IsNotFound() {
+							continue
+						}
+						return err
+					}
+				}
+
+				for _, b := range addMembers {
+					opts := gofastly.CreateDirectorBackendInput{
+						Service:  d.Id(),
+						Version:  latestVersion,
+						Director: name,
+						Backend:  b.(string),
+					}
+
+					log.Printf("[DEBUG] Create Director Backend Opts: %#v", opts)
+					_, err := conn.CreateDirectorBackend(&opts)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		// Find differences in Dictionaries. Dictionaries need to exist before any
+		// Header which looks them up
+		if d.HasChange("dictionary") {
+			od, nd := d.GetChange("dictionary")
+			if od == nil {
+				od = new(schema.Set)
+			}
+			if nd == nil {
+				nd = new(schema.Set)
+			}
+
+			ods := od.(*schema.Set)
+			nds := nd.(*schema.Set)
+
+			removeDictionaries := ods.Difference(nds).List()
+			addDictionaries := nds.Difference(ods).List()
+
+			// DELETE old Dictionaries
+			for _, dRaw := range removeDictionaries {
+				df := dRaw.(map[string]interface{})
+				opts := gofastly.DeleteDictionaryInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    df["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Dictionary Removal opts: %#v", opts)
+				err := conn.DeleteDictionary(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			// POST new Dictionaries
+			for _, dRaw := range addDictionaries {
+				df := dRaw.(map[string]interface{})
+				opts := gofastly.CreateDictionaryInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    df["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Dictionary Addition opts: %#v", opts)
+				_, err := conn.CreateDictionary(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
 		if d.HasChange("header") {
 			// Note: we don't utilize the PUT endpoint to update a Header, we simply
 			// destroy it and create a new one. This is how Terraform works with nested
@@ -611,9 +1516,10 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 			for _, dRaw := range add {
 				df := dRaw.(map[string]interface{})
 				opts := gofastly.CreateGzipInput{
-					Service: d.Id(),
-					Version: latestVersion,
-					Name:    df["name"].(string),
+					Service:        d.Id(),
+					Version:        latestVersion,
+					Name:           df["name"].(string),
+					CacheCondition: df["cache_condition"].(string),
 				}
 
 				// Fastly API will fill in ContentTypes or Extensions with default
@@ -634,21 +1540,17 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 				opts.ContentTypes = " "
 				if v, ok := df["content_types"]; ok {
 					if len(v.(*schema.Set).List()) > 0 {
-						var cl []string
-						for _, c := range v.(*schema.Set).List() {
-							cl = append(cl, c.(string))
+						contentTypes, err := expandGzipContentTypes(v.(*schema.Set))
+						if err != nil {
+							return err
 						}
-						opts.ContentTypes = strings.Join(cl, " ")
+						opts.ContentTypes = joinGzipSet(contentTypes)
 					}
 				}
 
 				if v, ok := df["extensions"]; ok {
 					if len(v.(*schema.Set).List()) > 0 {
-						var el []string
-						for _, e := range v.(*schema.Set).List() {
-							el = append(el, e.(string))
-						}
-						opts.Extensions = strings.Join(el, " ")
+						opts.Extensions = joinGzipSet(v.(*schema.Set))
 					}
 				}
 
@@ -660,79 +1562,374 @@ func resourceServiceV1Update(d *schema.ResourceData, meta interface{}) error {
 			}
 		}
 
-		// validate version
-		log.Printf("[DEBUG] Validating Fastly Service (%s), Version (%s)", d.Id(), latestVersion)
-		valid, msg, err := conn.ValidateVersion(&gofastly.ValidateVersionInput{
-			Service: d.Id(),
-			Version: latestVersion,
-		})
+		// Find differences in S3 logging endpoints
+		if d.HasChange("s3logging") || d.HasChange("default_log_condition") {
+			remove, add := loggingEndpointChanges(d, "s3logging")
 
-		if err != nil {
-			return fmt.Errorf("[ERR] Error checking validation: %s", err)
-		}
+			defaultLogCondition := d.Get("default_log_condition").(string)
 
-		if !valid {
-			return fmt.Errorf("[ERR] Invalid configuration for Fastly Service (%s): %s", d.Id(), msg)
-		}
+			// Services can have many S3 logging endpoints, so they are
+			// removed and then added through a bounded pool of workers
+			err := applyLoggingEndpoints("S3 logging", "deleting", remove, func(sf map[string]interface{}) error {
+				opts := gofastly.DeleteS3Input{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    sf["name"].(string),
+				}
 
-		log.Printf("[DEBUG] Activating Fastly Service (%s), Version (%s)", d.Id(), latestVersion)
-		_, err = conn.ActivateVersion(&gofastly.ActivateVersionInput{
-			Service: d.Id(),
-			Version: latestVersion,
-		})
-		if err != nil {
-			return fmt.Errorf("[ERR] Error activating version (%s): %s", latestVersion, err)
-		}
+				log.Printf("[DEBUG] Fastly S3 Removal opts: %#v", opts)
+				return conn.DeleteS3(&opts)
+			})
+			if err != nil {
+				return err
+			}
 
-		// Only if the version is valid and activated do we set the active_version.
-		// This prevents us from getting stuck in cloning an invalid version
-		d.Set("active_version", latestVersion)
-	}
+			err = applyLoggingEndpoints("S3 logging", "creating", add, func(sf map[string]interface{}) error {
+				opts := gofastly.CreateS3Input{
+					Service:           d.Id(),
+					Version:           latestVersion,
+					Name:              sf["name"].(string),
+					BucketName:        sf["bucket_name"].(string),
+					AccessKey:         sf["s3_access_key"].(string),
+					SecretKey:         sf["s3_secret_key"].(string),
+					Path:              normalizeS3LoggingPath(sf["path"]),
+					Period:            uint(sf["period"].(int)),
+					GzipLevel:         uint(sf["gzip_level"].(int)),
+					Format:            sf["format"].(string),
+					TimestampFormat:   sf["timestamp_format"].(string),
+					ResponseCondition: sf["response_condition"].(string),
+				}
+				if opts.ResponseCondition == "" {
+					opts.ResponseCondition = defaultLogCondition
+				}
 
-	return resourceServiceV1Read(d, meta)
-}
+				// Don't log the keys
+				logOpts := opts
+				logOpts.AccessKey = "<redacted>"
+				logOpts.SecretKey = "<redacted>"
+				log.Printf("[DEBUG] Fastly S3 Addition opts: %#v", logOpts)
+				_, err := conn.CreateS3(&opts)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-func resourceServiceV1Read(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*FastlyClient).conn
+		// Find differences in GCS logging endpoints
+		if d.HasChange("gcslogging") || d.HasChange("default_log_condition") {
+			// Like Gzips, changed endpoints are destroyed and created again
+			// rather than updated, on the new version of the configuration
+			remove, add := loggingEndpointChanges(d, "gcslogging")
 
-	// Find the Service. Discard the service because we need the ServiceDetails,
-	// not just a Service record
-	_, err := findService(d.Id(), meta)
-	if err != nil {
-		switch err {
-		case fastlyNoServiceFoundErr:
-			log.Printf("[WARN] %s for ID (%s)", err, d.Id())
-			d.SetId("")
-			return nil
-		default:
-			return err
-		}
-	}
+			defaultLogCondition := d.Get("default_log_condition").(string)
 
-	s, err := conn.GetServiceDetails(&gofastly.GetServiceInput{
-		ID: d.Id(),
-	})
+			// Delete removed GCS logging endpoints
+			for _, gRaw := range remove {
+				gf := gRaw.(map[string]interface{})
+				opts := gofastly.DeleteGCSInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    gf["name"].(string),
+				}
 
-	if err != nil {
-		return err
-	}
+				log.Printf("[DEBUG] Fastly GCS Removal opts: %#v", opts)
+				err := conn.DeleteGCS(&opts)
+				if err != nil {
+					return err
+				}
+			}
 
-	d.Set("name", s.Name)
-	d.Set("active_version", s.ActiveVersion.Number)
+			// POST new GCS logging endpoints
+			for _, gRaw := range add {
+				gf := gRaw.(map[string]interface{})
+				opts := gofastly.CreateGCSInput{
+					Service:           d.Id(),
+					Version:           latestVersion,
+					Name:              gf["name"].(string),
+					User:              gf["email"].(string),
+					Bucket:            gf["bucket_name"].(string),
+					SecretKey:         gf["secret_key"].(string),
+					Path:              gf["path"].(string),
+					Period:            uint(gf["period"].(int)),
+					GzipLevel:         uint8(gf["gzip_level"].(int)),
+					Format:            gf["format"].(string),
+					TimestampFormat:   gf["timestamp_format"].(string),
+					ResponseCondition: gf["response_condition"].(string),
+				}
+				if opts.ResponseCondition == "" {
+					opts.ResponseCondition = defaultLogCondition
+				}
+
+				// Don't log the secret key
+				logOpts := opts
+				logOpts.SecretKey = "<redacted>"
+				log.Printf("[DEBUG] Fastly GCS Addition opts: %#v", logOpts)
+				_, err := conn.CreateGCS(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Find differences in Papertrail logging endpoints
+		if d.HasChange("papertrail") || d.HasChange("default_log_condition") {
+			remove, add := loggingEndpointChanges(d, "papertrail")
+
+			defaultLogCondition := d.Get("default_log_condition").(string)
+
+			// Delete removed Papertrail logging endpoints
+			for _, pRaw := range remove {
+				pf := pRaw.(map[string]interface{})
+				opts := gofastly.DeletePapertrailInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    pf["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Papertrail Removal opts: %#v", opts)
+				err := conn.DeletePapertrail(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			// POST new Papertrail logging endpoints
+			for _, pRaw := range add {
+				pf := pRaw.(map[string]interface{})
+				opts := gofastly.CreatePapertrailInput{
+					Service:           d.Id(),
+					Version:           latestVersion,
+					Name:              pf["name"].(string),
+					Address:           pf["address"].(string),
+					Port:              uint(pf["port"].(int)),
+					Format:            pf["format"].(string),
+					ResponseCondition: pf["response_condition"].(string),
+				}
+				if opts.ResponseCondition == "" {
+					opts.ResponseCondition = defaultLogCondition
+				}
+
+				log.Printf("[DEBUG] Fastly Papertrail Addition opts: %#v", opts)
+				_, err := conn.CreatePapertrail(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Find differences in Syslog logging endpoints
+		if d.HasChange("syslog") || d.HasChange("default_log_condition") {
+			remove, add := loggingEndpointChanges(d, "syslog")
+
+			defaultLogCondition := d.Get("default_log_condition").(string)
+
+			// Delete removed Syslog logging endpoints
+			for _, sRaw := range remove {
+				sf := sRaw.(map[string]interface{})
+				opts := gofastly.DeleteSyslogInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    sf["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Syslog Removal opts: %#v", opts)
+				err := conn.DeleteSyslog(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			// POST new Syslog logging endpoints
+			for _, sRaw := range add {
+				sf := sRaw.(map[string]interface{})
+				opts := gofastly.CreateSyslogInput{
+					Service:           d.Id(),
+					Version:           latestVersion,
+					Name:              sf["name"].(string),
+					Address:           sf["address"].(string),
+					Port:              uint(sf["port"].(int)),
+					Token:             sf["token"].(string),
+					UseTLS:            gofastly.Compatibool(sf["use_tls"].(bool)),
+					TLSCACert:         normalizeSyslogCert(sf["tls_ca_cert"]),
+					Format:            sf["format"].(string),
+					ResponseCondition: sf["response_condition"].(string),
+				}
+				if opts.ResponseCondition == "" {
+					opts.ResponseCondition = defaultLogCondition
+				}
+
+				log.Printf("[DEBUG] Fastly Syslog Addition opts: %#v", opts)
+				_, err := conn.CreateSyslog(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Find differences in Sumo Logic logging endpoints
+		if d.HasChange("sumologic") || d.HasChange("default_log_condition") {
+			remove, add := loggingEndpointChanges(d, "sumologic")
+
+			defaultLogCondition := d.Get("default_log_condition").(string)
+
+			// Delete removed Sumo Logic logging endpoints
+			for _, sRaw := range remove {
+				sf := sRaw.(map[string]interface{})
+				opts := gofastly.DeleteSumologicInput{
+					Service: d.Id(),
+					Version: latestVersion,
+					Name:    sf["name"].(string),
+				}
+
+				log.Printf("[DEBUG] Fastly Sumo Logic Removal opts: %#v", opts)
+				err := conn.DeleteSumologic(&opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			// POST new Sumo Logic logging endpoints
+			for _, sRaw := range add {
+				sf := sRaw.(map[string]interface{})
+				opts := gofastly.CreateSumologicInput{
+					Service:           d.Id(),
+					Version:           latestVersion,
+					Name:              sf["name"].(string),
+					URL:               sf["url"].(string),
+					Format:            sf["format"].(string),
+					ResponseCondition: sf["response_condition"].(string),
+				}
+				if opts.ResponseCondition == "" {
+					opts.ResponseCondition = defaultLogCondition
+				}
+
+				log.Printf("[DEBUG] Fastly Sumo Logic Addition opts: %#v", opts)
+				_, err := conn.CreateSumologic(&opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if d.HasChange("force_tls") {
+			if err := updateForceTLS(conn, d.Id(), latestVersion, d.Get("force_tls").(bool)); err != nil {
+				return err
+			}
+		}
+
+		if d.HasChange("maintenance_mode") || d.HasChange("maintenance_page") {
+			o, n := d.GetChange("maintenance_mode")
+			if err := updateMaintenanceMode(conn, d.Id(), latestVersion, o.(bool), n.(bool), d.Get("maintenance_page").(string)); err != nil {
+				return err
+			}
+		}
+
+		// validate version
+		log.Printf("[DEBUG] Validating Fastly Service (%s), Version (%s)", d.Id(), latestVersion)
+		var valid bool
+		var msg string
+		var err error
+		if d.Get("treat_warnings_as_errors").(bool) {
+			valid, msg, err = validateServiceVersionStrict(conn, d.Id(), latestVersion)
+		} else {
+			valid, msg, err = conn.ValidateVersion(&gofastly.ValidateVersionInput{
+				Service: d.Id(),
+				Version: latestVersion,
+			})
+		}
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error checking validation: %s", err)
+		}
+
+		if !valid {
+			return fmt.Errorf("[ERR] Invalid configuration for Fastly Service (%s): %s", d.Id(), msg)
+		}
+
+		// Only if the version is valid do we set the cloned_version. This prevents
+		// us from getting stuck in cloning an invalid version
+		d.Set("cloned_version", latestVersion)
+	}
+
+	// Activate the most recently built version if it isn't already active
+	if activate {
+		latestVersion := d.Get("cloned_version").(string)
+		if isNewerVersion(latestVersion, d.Get("active_version").(string)) {
+			log.Printf("[DEBUG] Activating Fastly Service (%s), Version (%s)", d.Id(), latestVersion)
+			_, err := conn.ActivateVersion(&gofastly.ActivateVersionInput{
+				Service: d.Id(),
+				Version: latestVersion,
+			})
+			if err != nil {
+				return fmt.Errorf("[ERR] Error activating version (%s): %s", latestVersion, err)
+			}
+
+			// Only if the version is activated do we set the active_version
+			d.Set("active_version", latestVersion)
+		}
+	} else if needsChange {
+		log.Printf("[DEBUG] Fastly Service (%s), Version (%s) built but not activated", d.Id(), d.Get("cloned_version"))
+	}
+
+	return resourceServiceV1Read(d, meta)
+}
+
+func resourceServiceV1Read(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*FastlyClient).conn
+
+	// Find the Service. Discard the service because we need the ServiceDetails,
+	// not just a Service record
+	_, err := findService(d.Id(), meta)
+	if err != nil {
+		switch err {
+		case fastlyNoServiceFoundErr:
+			log.Printf("[WARN] %s for ID (%s)", err, d.Id())
+			d.SetId("")
+			return nil
+		default:
+			return err
+		}
+	}
+
+	s, err := conn.GetServiceDetails(&gofastly.GetServiceInput{
+		ID: d.Id(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	d.Set("name", s.Name)
+	d.Set("active_version", s.ActiveVersion.Number)
+	d.Set("last_version_comment", s.ActiveVersion.Comment)
+
+	// A version built but held back from activation by activation_token or
+	// stage_before_activate holds the configuration Terraform last applied, so
+	// read that in preference to the active version. With hold_activation the
+	// active version is read, so the held back changes are still planned.
+	cv := d.Get("cloned_version").(string)
+	if isNewerVersion(cv, s.ActiveVersion.Number) {
+		d.Set("staged_version", cv)
+	} else {
+		d.Set("cloned_version", s.ActiveVersion.Number)
+		d.Set("staged_version", "")
+	}
+	version := serviceV1ReadVersion(s.ActiveVersion.Number, cv, d.Get("hold_activation").(bool))
 
 	// If CreateService succeeds, but initial updates to the Service fail, we'll
 	// have an empty ActiveService version (no version is active, so we can't
 	// query for information on it)
-	if s.ActiveVersion.Number != "" {
+	if version != "" {
 		settingsOpts := gofastly.GetSettingsInput{
 			Service: d.Id(),
-			Version: s.ActiveVersion.Number,
+			Version: version,
 		}
 		if settings, err := conn.GetSettings(&settingsOpts); err == nil {
 			d.Set("default_host", settings.DefaultHost)
 			d.Set("default_ttl", settings.DefaultTTL)
 		} else {
-			return fmt.Errorf("[ERR] Error looking up Version settings for (%s), version (%s): %s", d.Id(), s.ActiveVersion.Number, err)
+			return fmt.Errorf("[ERR] Error looking up Version settings for (%s), version (%s): %s", d.Id(), version, err)
 		}
 
 		// TODO: update go-fastly to support an ActiveVersion struct, which contains
@@ -741,16 +1938,29 @@ func resourceServiceV1Read(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[DEBUG] Refreshing Domains for (%s)", d.Id())
 		domainList, err := conn.ListDomains(&gofastly.ListDomainsInput{
 			Service: d.Id(),
-			Version: s.ActiveVersion.Number,
+			Version: version,
 		})
 
 		if err != nil {
-			return fmt.Errorf("[ERR] Error looking up Domains for (%s), version (%s): %s", d.Id(), s.ActiveVersion.Number, err)
+			return fmt.Errorf("[ERR] Error looking up Domains for (%s), version (%s): %s", d.Id(), version, err)
 		}
 
 		// Refresh Domains
 		dl := flattenDomains(domainList)
 
+		// CNAME statuses are informational only, so failing to read them is
+		// logged rather than failing the refresh
+		log.Printf("[DEBUG] Refreshing Domain CNAME statuses for (%s)", d.Id())
+		cnameStatuses, err := readDomainCNAMEStatuses(conn, d.Id(), version)
+		if err != nil {
+			log.Printf("[WARN] Error checking Domains for (%s), version (%s): %s", d.Id(), version, err)
+		}
+		for _, domain := range dl {
+			if status, ok := cnameStatuses[domain["name"].(string)]; ok {
+				domain["cname_status"] = status
+			}
+		}
+
 		if err := d.Set("domain", dl); err != nil {
 			log.Printf("[WARN] Error setting Domains for (%s): %s", d.Id(), err)
 		}
@@ -759,11 +1969,11 @@ func resourceServiceV1Read(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[DEBUG] Refreshing Backends for (%s)", d.Id())
 		backendList, err := conn.ListBackends(&gofastly.ListBackendsInput{
 			Service: d.Id(),
-			Version: s.ActiveVersion.Number,
+			Version: version,
 		})
 
 		if err != nil {
-			return fmt.Errorf("[ERR] Error looking up Backends for (%s), version (%s): %s", d.Id(), s.ActiveVersion.Number, err)
+			return fmt.Errorf("[ERR] Error looking up Backends for (%s), version (%s): %s", d.Id(), version, err)
 		}
 
 		bl := flattenBackends(backendList)
@@ -772,15 +1982,110 @@ func resourceServiceV1Read(d *schema.ResourceData, meta interface{}) error {
 			log.Printf("[WARN] Error setting Backends for (%s): %s", d.Id(), err)
 		}
 
+		// refresh Healthchecks
+		log.Printf("[DEBUG] Refreshing Healthchecks for (%s)", d.Id())
+		healthcheckList, err := conn.ListHealthChecks(&gofastly.ListHealthChecksInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Healthchecks for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		hcl := flattenHealthchecks(healthcheckList)
+
+		if err := d.Set("healthcheck", hcl); err != nil {
+			log.Printf("[WARN] Error setting Healthchecks for (%s): %s", d.Id(), err)
+		}
+
+		// Refresh Directors
+		log.Printf("[DEBUG] Refreshing Directors for (%s)", d.Id())
+		directorList, err := conn.ListDirectors(&gofastly.ListDirectorsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Directors for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		// The Fastly API has no endpoint listing the members of a Director, so
+		// membership is determined by looking up each of the service's Backends
+		directorBackends := make(map[string][]string)
+		for _, dr := range directorList {
+			for _, b := range backendList {
+				_, err := conn.GetDirectorBackend(&gofastly.GetDirectorBackendInput{
+					Service:  d.Id(),
+					Version:  version,
+					Director: dr.Name,
+					Backend:  b.Name,
+				})
+				if err != nil {
+					if herr, ok := err.(*gofastly.HTTPError); ok && herr.IsNotFound() {
+						continue
+					}
+					return fmt.Errorf("[ERR] Error looking up Backend (%s) of Director (%s) for (%s), version (%s): %s", b.Name, dr.Name, d.Id(), version, err)
+				}
+				directorBackends[dr.Name] = append(directorBackends[dr.Name], b.Name)
+			}
+		}
+
+		drl := flattenDirectors(directorList, directorBackends)
+
+		if err := d.Set("director", drl); err != nil {
+			log.Printf("[WARN] Error setting Directors for (%s): %s", d.Id(), err)
+		}
+
+		// refresh Conditions
+		log.Printf("[DEBUG] Refreshing Conditions for (%s)", d.Id())
+		conditionList, err := conn.ListConditions(&gofastly.ListConditionsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Conditions for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		cl, cm := splitConditions(flattenConditions(conditionList), d.Get("conditions").(map[string]interface{}),
+			d.Get("conditions_type").(string), d.Get("conditions_priority").(int))
+		preserveConditionExpressions(cl, d.Get("condition").(*schema.Set))
+		preserveDefaultConditions(cl, d.Get("condition").(*schema.Set))
+
+		if err := d.Set("condition", cl); err != nil {
+			log.Printf("[WARN] Error setting Conditions for (%s): %s", d.Id(), err)
+		}
+		if err := d.Set("conditions", cm); err != nil {
+			log.Printf("[WARN] Error setting Conditions map for (%s): %s", d.Id(), err)
+		}
+
+		// refresh Dictionaries
+		log.Printf("[DEBUG] Refreshing Dictionaries for (%s)", d.Id())
+		dictionaryList, err := conn.ListDictionaries(&gofastly.ListDictionariesInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Dictionaries for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		dictl := flattenDictionaries(dictionaryList)
+
+		if err := d.Set("dictionary", dictl); err != nil {
+			log.Printf("[WARN] Error setting Dictionaries for (%s): %s", d.Id(), err)
+		}
+
 		// refresh headers
 		log.Printf("[DEBUG] Refreshing Headers for (%s)", d.Id())
 		headerList, err := conn.ListHeaders(&gofastly.ListHeadersInput{
 			Service: d.Id(),
-			Version: s.ActiveVersion.Number,
+			Version: version,
 		})
 
 		if err != nil {
-			return fmt.Errorf("[ERR] Error looking up Headers for (%s), version (%s): %s", d.Id(), s.ActiveVersion.Number, err)
+			return fmt.Errorf("[ERR] Error looking up Headers for (%s), version (%s): %s", d.Id(), version, err)
 		}
 
 		hl := flattenHeaders(headerList)
@@ -789,55 +2094,217 @@ func resourceServiceV1Read(d *schema.ResourceData, meta interface{}) error {
 			log.Printf("[WARN] Error setting Headers for (%s): %s", d.Id(), err)
 		}
 
+		// refresh force_tls
+		log.Printf("[DEBUG] Refreshing Request Settings for (%s)", d.Id())
+		rsList, err := conn.ListRequestSettings(&gofastly.ListRequestSettingsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Request Settings for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		var forceTLS bool
+		for _, rs := range rsList {
+			if rs.Name == fastlyForceTLSRequestSettingName {
+				forceTLS = true
+			}
+		}
+		d.Set("force_tls", forceTLS)
+
+		// refresh maintenance_mode
+		log.Printf("[DEBUG] Refreshing Response Objects for (%s)", d.Id())
+		roList, err := conn.ListResponseObjects(&gofastly.ListResponseObjectsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Response Objects for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		var maintenanceMode bool
+		for _, ro := range roList {
+			if ro.Name == fastlyMaintenanceModeName {
+				maintenanceMode = true
+				d.Set("maintenance_page", ro.Content)
+			}
+		}
+		d.Set("maintenance_mode", maintenanceMode)
+
 		// refresh gzips
 		log.Printf("[DEBUG] Refreshing Gzips for (%s)", d.Id())
 		gzipsList, err := conn.ListGzips(&gofastly.ListGzipsInput{
 			Service: d.Id(),
-			Version: s.ActiveVersion.Number,
+			Version: version,
 		})
 
 		if err != nil {
-			return fmt.Errorf("[ERR] Error looking up Gzips for (%s), version (%s): %s", d.Id(), s.ActiveVersion.Number, err)
+			return fmt.Errorf("[ERR] Error looking up Gzips for (%s), version (%s): %s", d.Id(), version, err)
 		}
 
 		gl := flattenGzips(gzipsList)
+		collapseGzipContentTypes(gl, d.Get("gzip").(*schema.Set))
 
 		if err := d.Set("gzip", gl); err != nil {
 			log.Printf("[WARN] Error setting Gzips for (%s): %s", d.Id(), err)
 		}
 
-	} else {
-		log.Printf("[DEBUG] Active Version for Service (%s) is empty, no state to refresh", d.Id())
-	}
+		// refresh S3 logging endpoints
+		log.Printf("[DEBUG] Refreshing S3 for (%s)", d.Id())
+		s3List, err := conn.ListS3s(&gofastly.ListS3sInput{
+			Service: d.Id(),
+			Version: version,
+		})
 
-	return nil
-}
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up S3 for (%s), version (%s): %s", d.Id(), version, err)
+		}
 
-func resourceServiceV1Delete(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*FastlyClient).conn
+		s3l := flattenS3s(s3List)
+		preserveDefaultLogCondition(s3l, d.Get("s3logging").(*schema.Set), d.Get("default_log_condition").(string))
 
-	// Fastly will fail to delete any service with an Active Version.
-	// If `force_destroy` is given, we deactivate the active version and then send
-	// the DELETE call
-	if d.Get("force_destroy").(bool) {
-		s, err := conn.GetServiceDetails(&gofastly.GetServiceInput{
-			ID: d.Id(),
+		if err := d.Set("s3logging", s3l); err != nil {
+			log.Printf("[WARN] Error setting s3logging for (%s): %s", d.Id(), err)
+		}
+
+		// refresh GCS logging endpoints
+		log.Printf("[DEBUG] Refreshing GCS for (%s)", d.Id())
+		gcsList, err := conn.ListGCSs(&gofastly.ListGCSsInput{
+			Service: d.Id(),
+			Version: version,
 		})
 
 		if err != nil {
-			return err
+			return fmt.Errorf("[ERR] Error looking up GCS for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		gcsl := flattenGCSs(gcsList)
+		preserveDefaultLogCondition(gcsl, d.Get("gcslogging").(*schema.Set), d.Get("default_log_condition").(string))
+
+		if err := d.Set("gcslogging", gcsl); err != nil {
+			log.Printf("[WARN] Error setting gcslogging for (%s): %s", d.Id(), err)
 		}
 
+		// refresh Papertrail logging endpoints
+		log.Printf("[DEBUG] Refreshing Papertrail for (%s)", d.Id())
+		papertrailList, err := conn.ListPapertrails(&gofastly.ListPapertrailsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Papertrail for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		pl := flattenPapertrails(papertrailList)
+		preserveDefaultLogCondition(pl, d.Get("papertrail").(*schema.Set), d.Get("default_log_condition").(string))
+
+		if err := d.Set("papertrail", pl); err != nil {
+			log.Printf("[WARN] Error setting papertrail for (%s): %s", d.Id(), err)
+		}
+
+		// refresh Syslog logging endpoints
+		log.Printf("[DEBUG] Refreshing Syslog for (%s)", d.Id())
+		syslogList, err := conn.ListSyslogs(&gofastly.ListSyslogsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Syslog for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		sll := flattenSyslogs(syslogList)
+		preserveDefaultLogCondition(sll, d.Get("syslog").(*schema.Set), d.Get("default_log_condition").(string))
+
+		if err := d.Set("syslog", sll); err != nil {
+			log.Printf("[WARN] Error setting syslog for (%s): %s", d.Id(), err)
+		}
+
+		// refresh Sumo Logic logging endpoints
+		log.Printf("[DEBUG] Refreshing Sumo Logic for (%s)", d.Id())
+		sumologicList, err := conn.ListSumologics(&gofastly.ListSumologicsInput{
+			Service: d.Id(),
+			Version: version,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Sumo Logic for (%s), version (%s): %s", d.Id(), version, err)
+		}
+
+		sul := flattenSumologics(sumologicList)
+		preserveDefaultLogCondition(sul, d.Get("sumologic").(*schema.Set), d.Get("default_log_condition").(string))
+
+		if err := d.Set("sumologic", sul); err != nil {
+			log.Printf("[WARN] Error setting sumologic for (%s): %s", d.Id(), err)
+		}
+
+		// refresh generated VCL. This is the VCL Fastly is serving, so it is read
+		// from the active version even when a newer version has been built
 		if s.ActiveVersion.Number != "" {
-			_, err := conn.DeactivateVersion(&gofastly.DeactivateVersionInput{
+			log.Printf("[DEBUG] Refreshing Generated VCL for (%s)", d.Id())
+			vcl, err := conn.GetGeneratedVCL(&gofastly.GetGeneratedVCLInput{
 				Service: d.Id(),
 				Version: s.ActiveVersion.Number,
 			})
+
 			if err != nil {
-				return err
+				return fmt.Errorf("[ERR] Error looking up Generated VCL for (%s), version (%s): %s", d.Id(), s.ActiveVersion.Number, err)
 			}
+
+			d.Set("generated_vcl", vcl.Content)
 		}
-	}
+
+	} else {
+		log.Printf("[DEBUG] Active Version for Service (%s) is empty, no state to refresh", d.Id())
+	}
+
+	// Stats are informational only, so failing to read them is logged rather
+	// than failing the refresh
+	stats := map[string]interface{}{}
+	if d.Get("collect_stats").(bool) {
+		log.Printf("[DEBUG] Refreshing Stats for (%s)", d.Id())
+		ss, err := readServiceStats(conn, d.Id())
+		if err != nil {
+			log.Printf("[WARN] Error looking up Stats for (%s): %s", d.Id(), err)
+		} else {
+			stats = ss
+		}
+	}
+	if err := d.Set("stats", stats); err != nil {
+		log.Printf("[WARN] Error setting Stats for (%s): %s", d.Id(), err)
+	}
+
+	return nil
+}
+
+func resourceServiceV1Delete(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*FastlyClient).conn
+
+	// Fastly will fail to delete any service with an Active Version.
+	// If `force_destroy` is given, we deactivate the active version and then send
+	// the DELETE call
+	if d.Get("force_destroy").(bool) {
+		s, err := conn.GetServiceDetails(&gofastly.GetServiceInput{
+			ID: d.Id(),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if s.ActiveVersion.Number != "" {
+			_, err := conn.DeactivateVersion(&gofastly.DeactivateVersionInput{
+				Service: d.Id(),
+				Version: s.ActiveVersion.Number,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	err := conn.DeleteService(&gofastly.DeleteServiceInput{
 		ID: d.Id(),
@@ -893,6 +2360,7 @@ func flattenBackends(backendList []*gofastly.Backend) []map[string]interface{} {
 			"port":                  int(b.Port),
 			"ssl_check_cert":        b.SSLCheckCert,
 			"weight":                int(b.Weight),
+			"healthcheck":           b.HealthCheck,
 		}
 
 		bl = append(bl, nb)
@@ -934,6 +2402,12 @@ func findService(id string, meta interface{}) (*gofastly.Service, error) {
 func flattenHeaders(headerList []*gofastly.Header) []map[string]interface{} {
 	var hl []map[string]interface{}
 	for _, h := range headerList {
+		// The force_tls header is managed by the force_tls option, not as a
+		// user declared header
+		if h.Name == fastlyForceTLSHeaderName {
+			continue
+		}
+
 		// Convert Header to a map for saving to state.
 		nh := map[string]interface{}{
 			"name":               h.Name,
@@ -1018,22 +2492,72 @@ func flattenGzips(gzipsList []*gofastly.Gzip) []map[string]interface{} {
 		// event that you do not specify them. To work around this, if they are
 		// omitted we'll use an empty space as a sentinel value to indicate not to
 		// include them, and filter on that
-		if g.Extensions != "" && g.Extensions != " " {
-			e := strings.Split(g.Extensions, " ")
-			var et []interface{}
-			for _, ev := range e {
-				et = append(et, ev)
+		if e := splitGzipList(g.Extensions); len(e) > 0 {
+			ng["extensions"] = schema.NewSet(schema.HashString, e)
+		}
+
+		if c := splitGzipList(g.ContentTypes); len(c) > 0 {
+			ng["content_types"] = schema.NewSet(schema.HashString, c)
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range ng {
+			if v == "" {
+				delete(ng, k)
 			}
-			ng["extensions"] = schema.NewSet(schema.HashString, et)
 		}
 
-		if g.ContentTypes != "" && g.ContentTypes != " " {
-			c := strings.Split(g.ContentTypes, " ")
-			var ct []interface{}
-			for _, cv := range c {
-				ct = append(ct, cv)
+		gl = append(gl, ng)
+	}
+
+	return gl
+}
+
+func flattenS3s(s3List []*gofastly.S3) []map[string]interface{} {
+	var sl []map[string]interface{}
+	for _, s := range s3List {
+		// Convert S3 to a map for saving to state.
+		ns := map[string]interface{}{
+			"name":               s.Name,
+			"bucket_name":        s.BucketName,
+			"s3_access_key":      s.AccessKey,
+			"s3_secret_key":      s.SecretKey,
+			"path":               normalizeS3LoggingPath(s.Path),
+			"period":             int(s.Period),
+			"gzip_level":         int(s.GzipLevel),
+			"format":             s.Format,
+			"timestamp_format":   s.TimestampFormat,
+			"response_condition": s.ResponseCondition,
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range ns {
+			if v == "" {
+				delete(ns, k)
 			}
-			ng["content_types"] = schema.NewSet(schema.HashString, ct)
+		}
+
+		sl = append(sl, ns)
+	}
+
+	return sl
+}
+
+func flattenGCSs(gcsList []*gofastly.GCS) []map[string]interface{} {
+	var gl []map[string]interface{}
+	for _, g := range gcsList {
+		// Convert GCS to a map for saving to state.
+		ng := map[string]interface{}{
+			"name":               g.Name,
+			"email":              g.User,
+			"bucket_name":        g.Bucket,
+			"secret_key":         g.SecretKey,
+			"path":               g.Path,
+			"period":             int(g.Period),
+			"gzip_level":         int(g.GzipLevel),
+			"format":             g.Format,
+			"timestamp_format":   g.TimestampFormat,
+			"response_condition": g.ResponseCondition,
 		}
 
 		// prune any empty values that come from the default string value in structs
@@ -1048,3 +2572,1481 @@ func flattenGzips(gzipsList []*gofastly.Gzip) []map[string]interface{} {
 
 	return gl
 }
+
+func flattenPapertrails(papertrailList []*gofastly.Papertrail) []map[string]interface{} {
+	var pl []map[string]interface{}
+	for _, p := range papertrailList {
+		// Convert Papertrail to a map for saving to state.
+		np := map[string]interface{}{
+			"name":               p.Name,
+			"address":            p.Address,
+			"port":               int(p.Port),
+			"format":             p.Format,
+			"response_condition": p.ResponseCondition,
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range np {
+			if v == "" {
+				delete(np, k)
+			}
+		}
+
+		pl = append(pl, np)
+	}
+
+	return pl
+}
+
+func flattenSyslogs(syslogList []*gofastly.Syslog) []map[string]interface{} {
+	var sl []map[string]interface{}
+	for _, l := range syslogList {
+		// Convert Syslog to a map for saving to state.
+		ns := map[string]interface{}{
+			"name":               l.Name,
+			"address":            l.Address,
+			"port":               int(l.Port),
+			"token":              l.Token,
+			"use_tls":            l.UseTLS,
+			"tls_ca_cert":        normalizeSyslogCert(l.TLSCACert),
+			"format":             l.Format,
+			"response_condition": l.ResponseCondition,
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range ns {
+			if v == "" {
+				delete(ns, k)
+			}
+		}
+
+		sl = append(sl, ns)
+	}
+
+	return sl
+}
+
+func flattenSumologics(sumologicList []*gofastly.Sumologic) []map[string]interface{} {
+	var sl []map[string]interface{}
+	for _, l := range sumologicList {
+		// Convert Sumo Logic to a map for saving to state.
+		ns := map[string]interface{}{
+			"name":               l.Name,
+			"url":                l.URL,
+			"format":             l.Format,
+			"response_condition": l.ResponseCondition,
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range ns {
+			if v == "" {
+				delete(ns, k)
+			}
+		}
+
+		sl = append(sl, ns)
+	}
+
+	return sl
+}
+
+func flattenConditions(conditionList []*gofastly.Condition) []map[string]interface{} {
+	var cl []map[string]interface{}
+	for _, c := range conditionList {
+		// The maintenance_mode condition is managed by the maintenance_mode
+		// option, not as a user declared condition
+		if c.Name == fastlyMaintenanceModeName {
+			continue
+		}
+
+		// Convert Condition to a map for saving to state.
+		nc := map[string]interface{}{
+			"name":      c.Name,
+			"statement": c.Statement,
+			"type":      c.Type,
+			"priority":  c.Priority,
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range nc {
+			if v == "" {
+				delete(nc, k)
+			}
+		}
+
+		cl = append(cl, nc)
+	}
+
+	return cl
+}
+
+// flattenHealthchecks converts Healthchecks to maps for saving to state. Every
+// field is read back, so that changes made outside of Terraform show up as a
+// diff.
+func flattenHealthchecks(healthcheckList []*gofastly.HealthCheck) []map[string]interface{} {
+	var hl []map[string]interface{}
+	for _, h := range healthcheckList {
+		hl = append(hl, map[string]interface{}{
+			"name":              h.Name,
+			"host":              h.Host,
+			"path":              h.Path,
+			"check_interval":    int(h.CheckInterval),
+			"expected_response": int(h.ExpectedResponse),
+			"http_version":      h.HTTPVersion,
+			"initial":           int(h.Initial),
+			"method":            h.Method,
+			"threshold":         int(h.Threshold),
+			"timeout":           int(h.Timeout),
+			"window":            int(h.Window),
+		})
+	}
+
+	return hl
+}
+
+func flattenDictionaries(dictionaryList []*gofastly.Dictionary) []map[string]interface{} {
+	var dl []map[string]interface{}
+	for _, dict := range dictionaryList {
+		dl = append(dl, map[string]interface{}{
+			"name": dict.Name,
+		})
+	}
+
+	return dl
+}
+
+func flattenDirectors(directorList []*gofastly.Director, directorBackends map[string][]string) []map[string]interface{} {
+	var dl []map[string]interface{}
+	for _, dr := range directorList {
+		var backends []interface{}
+		for _, b := range directorBackends[dr.Name] {
+			backends = append(backends, b)
+		}
+
+		// Convert Director to a map for saving to state.
+		nd := map[string]interface{}{
+			"name":     dr.Name,
+			"comment":  dr.Comment,
+			"quorum":   int(dr.Quorum),
+			"type":     int(dr.Type),
+			"retries":  int(dr.Retries),
+			"backends": schema.NewSet(schema.HashString, backends),
+		}
+
+		// prune any empty values that come from the default string value in structs
+		for k, v := range nd {
+			if v == "" {
+				delete(nd, k)
+			}
+		}
+
+		dl = append(dl, nd)
+	}
+
+	return dl
+}
+
+// directorsByName indexes a set of director blocks by their name.
+func directorsByName(directors *schema.Set) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{})
+	for _, dRaw := range directors.List() {
+		df := dRaw.(map[string]interface{})
+		m[df["name"].(string)] = df
+	}
+	return m
+}
+
+// validateBackendHealthchecks checks that every healthcheck associated with a
+// backend is declared in the healthcheck set.
+func validateBackendHealthchecks(d *schema.ResourceData) error {
+	healthchecks := make(map[string]bool)
+	for _, hRaw := range d.Get("healthcheck").(*schema.Set).List() {
+		hf := hRaw.(map[string]interface{})
+		healthchecks[hf["name"].(string)] = true
+	}
+
+	for _, bRaw := range d.Get("backend").(*schema.Set).List() {
+		bf := bRaw.(map[string]interface{})
+		if h := bf["healthcheck"].(string); h != "" && !healthchecks[h] {
+			return fmt.Errorf("[ERR] Backend (%s) references healthcheck (%s), which is not a declared healthcheck", bf["name"], h)
+		}
+	}
+
+	return nil
+}
+
+// validateDirectorBackends checks that every backend referenced by a director
+// is declared in the backend set.
+func validateDirectorBackends(d *schema.ResourceData) error {
+	backends := make(map[string]bool)
+	for _, bRaw := range d.Get("backend").(*schema.Set).List() {
+		bf := bRaw.(map[string]interface{})
+		backends[bf["name"].(string)] = true
+	}
+
+	for _, dRaw := range d.Get("director").(*schema.Set).List() {
+		df := dRaw.(map[string]interface{})
+		for _, b := range df["backends"].(*schema.Set).List() {
+			if !backends[b.(string)] {
+				return fmt.Errorf("[ERR] Director (%s) references backend (%s), which is not a declared backend", df["name"], b)
+			}
+		}
+	}
+
+	return nil
+}
+
+// conditionVariablePattern matches a reference to a VCL variable in one of
+// the namespaces available to conditions, e.g. req.http.host or beresp.status
+var conditionVariablePattern = regexp.MustCompile(
+	`(^|[^\w.])(req|bereq|beresp|resp|obj|client|server|fastly|geoip|time)\.\w`)
+
+// conditionStatement returns the VCL statement for a condition, either as
+// given in statement or generated by combining its expressions with its
+// operator. Each expression is wrapped in parentheses, so that operators
+// within an expression bind as written.
+func conditionStatement(cf map[string]interface{}) (string, error) {
+	name := cf["name"].(string)
+	statement, _ := cf["statement"].(string)
+
+	var exprs []string
+	if raw, ok := cf["expressions"].([]interface{}); ok {
+		for _, e := range raw {
+			exprs = append(exprs, e.(string))
+		}
+	}
+
+	if len(exprs) == 0 {
+		if statement == "" {
+			return "", fmt.Errorf("[ERR] Condition (%s) requires either a statement or expressions", name)
+		}
+		return statement, nil
+	}
+	if statement != "" {
+		return "", fmt.Errorf("[ERR] Condition (%s) cannot set both statement and expressions", name)
+	}
+
+	join := " && "
+	switch op, _ := cf["operator"].(string); op {
+	case "", "and":
+	case "or":
+		join = " || "
+	default:
+		return "", fmt.Errorf("[ERR] Condition (%s) has an unknown operator (%s)", name, op)
+	}
+
+	parts := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		e = strings.TrimSpace(e)
+		if !conditionVariablePattern.MatchString(e) {
+			return "", fmt.Errorf("[ERR] Condition (%s) expression (%s) does not reference a known VCL variable", name, e)
+		}
+		parts = append(parts, "("+e+")")
+	}
+
+	return strings.Join(parts, join), nil
+}
+
+// validateConditionType checks that a Condition type is one Fastly accepts.
+func validateConditionType(v interface{}, k string) (ws []string, es []error) {
+	var found bool
+	for _, t := range []string{"REQUEST", "RESPONSE", "CACHE"} {
+		if v.(string) == t {
+			found = true
+		}
+	}
+	if !found {
+		es = append(es, fmt.Errorf(
+			"Fastly Condition type is case sensitive and must be one of 'REQUEST', 'RESPONSE', or 'CACHE'; found: %s", v.(string)))
+	}
+	return
+}
+
+// validateConditionsMap checks that no name in the conditions map is reserved.
+func validateConditionsMap(v interface{}, k string) (ws []string, es []error) {
+	for name := range v.(map[string]interface{}) {
+		if name == fastlyMaintenanceModeName {
+			es = append(es, fmt.Errorf(
+				"Fastly Condition name %q is reserved for use by maintenance_mode", name))
+		}
+	}
+	return
+}
+
+// validateConditionNames checks that no Condition is declared both in a
+// condition block and in the conditions map.
+func validateConditionNames(d *schema.ResourceData) error {
+	conditions := d.Get("conditions").(map[string]interface{})
+	for _, cRaw := range d.Get("condition").(*schema.Set).List() {
+		name := cRaw.(map[string]interface{})["name"].(string)
+		if _, ok := conditions[name]; ok {
+			return fmt.Errorf("[ERR] Condition (%s) is declared both in a condition block and in conditions", name)
+		}
+	}
+	return nil
+}
+
+// conditionSet returns every Condition of the service: those declared in
+// condition blocks and those in the conditions map.
+func conditionSet(d *schema.ResourceData) *schema.Set {
+	return mergeConditions(d.Get("condition").(*schema.Set), d.Get("conditions"),
+		d.Get("conditions_type").(string), d.Get("conditions_priority").(int))
+}
+
+// mergeConditions adds the Conditions in the conditions map, each with the
+// given type and priority, to a copy of the condition set. Conditions
+// declared either way hash the same, so moving one between the two doesn't
+// change the service.
+func mergeConditions(set *schema.Set, conditions interface{}, conditionType string, priority int) *schema.Set {
+	merged := schema.NewSet(set.F, set.List())
+	cm, _ := conditions.(map[string]interface{})
+	for name, statement := range cm {
+		merged.Add(map[string]interface{}{
+			"name":        name,
+			"statement":   statement.(string),
+			"expressions": []interface{}{},
+			"operator":    "",
+			"type":        conditionType,
+			"priority":    priority,
+			"default":     false,
+		})
+	}
+	return merged
+}
+
+// splitConditions moves the Conditions declared in the conditions map out of
+// the flattened Conditions cl, returning the rest and the map. A Condition
+// whose type or priority no longer matches the map's is left in the set, so
+// the drift shows up as a diff.
+func splitConditions(cl []map[string]interface{}, configured map[string]interface{}, conditionType string, priority int) ([]map[string]interface{}, map[string]interface{}) {
+	var rest []map[string]interface{}
+	cm := make(map[string]interface{})
+	for _, c := range cl {
+		name, _ := c["name"].(string)
+		if _, ok := configured[name]; ok && c["type"] == conditionType && c["priority"] == priority {
+			statement, _ := c["statement"].(string)
+			cm[name] = statement
+			continue
+		}
+		rest = append(rest, c)
+	}
+	return rest, cm
+}
+
+// validateConditionStatements checks that every condition has a statement,
+// or expressions which generate a valid one.
+func validateConditionStatements(d *schema.ResourceData) error {
+	for _, cRaw := range conditionSet(d).List() {
+		if _, err := conditionStatement(cRaw.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preserveConditionExpressions restores expressions and operator on refreshed
+// conditions which were generated from them. Fastly only stores the combined
+// statement, so without this a condition written with expressions would
+// always show a diff. A statement which no longer matches the configured
+// expressions is left as is, so the drift is shown.
+func preserveConditionExpressions(cl []map[string]interface{}, configured *schema.Set) {
+	generated := make(map[string]map[string]interface{})
+	for _, cRaw := range configured.List() {
+		cf := cRaw.(map[string]interface{})
+		if exprs, ok := cf["expressions"].([]interface{}); !ok || len(exprs) == 0 {
+			continue
+		}
+		statement, err := conditionStatement(cf)
+		if err != nil {
+			continue
+		}
+		generated[cf["name"].(string)+"\x00"+statement] = cf
+	}
+
+	for _, c := range cl {
+		name, _ := c["name"].(string)
+		statement, _ := c["statement"].(string)
+		cf, ok := generated[name+"\x00"+statement]
+		if !ok {
+			continue
+		}
+		delete(c, "statement")
+		c["expressions"] = cf["expressions"]
+		if op := cf["operator"].(string); op != "" {
+			c["operator"] = op
+		}
+	}
+}
+
+// dictionaryLookupPattern matches the dictionary name in a VCL
+// table.lookup(name, key) call
+var dictionaryLookupPattern = regexp.MustCompile(`table\.lookup\(\s*([A-Za-z0-9_]+)`)
+
+// defaultConditionPriorities returns the priority of each Condition with
+// default set, by name: one more than the highest priority of the other
+// Conditions of its type, so that it executes after all of them.
+func defaultConditionPriorities(conditions *schema.Set) map[string]int {
+	highest := make(map[string]int)
+	for _, cRaw := range conditions.List() {
+		cf := cRaw.(map[string]interface{})
+		if cf["default"].(bool) {
+			continue
+		}
+		t := cf["type"].(string)
+		if p := cf["priority"].(int); p > highest[t] {
+			highest[t] = p
+		}
+	}
+
+	priorities := make(map[string]int)
+	for _, cRaw := range conditions.List() {
+		cf := cRaw.(map[string]interface{})
+		if cf["default"].(bool) {
+			priorities[cf["name"].(string)] = highest[cf["type"].(string)] + 1
+		}
+	}
+	return priorities
+}
+
+// validateDefaultConditions checks that there is at most one default
+// Condition of each type.
+func validateDefaultConditions(d *schema.ResourceData) error {
+	defaults := make(map[string]string)
+	for _, cRaw := range conditionSet(d).List() {
+		cf := cRaw.(map[string]interface{})
+		if !cf["default"].(bool) {
+			continue
+		}
+		t := cf["type"].(string)
+		if other, ok := defaults[t]; ok {
+			return fmt.Errorf("[ERR] Conditions (%s) and (%s) are both the default %s condition, only one is allowed", other, cf["name"], t)
+		}
+		defaults[t] = cf["name"].(string)
+	}
+	return nil
+}
+
+// preserveDefaultConditions marks the refreshed Conditions which are
+// configured as default, and restores their configured priority in place of
+// the one generated for them, so that it isn't reported as a change.
+func preserveDefaultConditions(cl []map[string]interface{}, configured *schema.Set) {
+	defaults := make(map[string]map[string]interface{})
+	for _, cRaw := range configured.List() {
+		cf := cRaw.(map[string]interface{})
+		if cf["default"].(bool) {
+			defaults[cf["name"].(string)] = cf
+		}
+	}
+
+	for _, c := range cl {
+		name, _ := c["name"].(string)
+		cf, ok := defaults[name]
+		if !ok || c["type"] != cf["type"] {
+			continue
+		}
+		c["default"] = true
+		c["priority"] = cf["priority"]
+	}
+}
+
+// validateDictionaryReferences checks that every dictionary looked up by a
+// header's source or substitution is declared in the dictionary set.
+func validateDictionaryReferences(d *schema.ResourceData) error {
+	declared := make(map[string]bool)
+	for _, dRaw := range d.Get("dictionary").(*schema.Set).List() {
+		df := dRaw.(map[string]interface{})
+		declared[df["name"].(string)] = true
+	}
+
+	for _, hRaw := range d.Get("header").(*schema.Set).List() {
+		hf := hRaw.(map[string]interface{})
+		for _, field := range []string{"source", "substitution"} {
+			for _, m := range dictionaryLookupPattern.FindAllStringSubmatch(hf[field].(string), -1) {
+				if !declared[m[1]] {
+					return fmt.Errorf("[ERR] Header (%s) %s looks up dictionary (%s), which is not a declared dictionary", hf["name"], field, m[1])
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// fastlyVersionCommentMaxLength is the longest comment Terraform will store
+// on a version.
+const fastlyVersionCommentMaxLength = 255
+
+// validateVersionComment checks that version_comment fits on a version.
+func validateVersionComment(v interface{}, k string) (ws []string, es []error) {
+	if comment := v.(string); len(comment) > fastlyVersionCommentMaxLength {
+		es = append(es, fmt.Errorf(
+			"%q must be at most %d characters; found: %d", k, fastlyVersionCommentMaxLength, len(comment)))
+	}
+	return
+}
+
+// validateGzipConditions checks that every cache_condition referenced by a
+// gzip rule is declared in the condition set with the CACHE type.
+func validateGzipConditions(d *schema.ResourceData) error {
+	conditionTypes := make(map[string]string)
+	for _, cRaw := range conditionSet(d).List() {
+		cf := cRaw.(map[string]interface{})
+		conditionTypes[cf["name"].(string)] = cf["type"].(string)
+	}
+
+	for _, gRaw := range d.Get("gzip").(*schema.Set).List() {
+		gf := gRaw.(map[string]interface{})
+		name := gf["cache_condition"].(string)
+		if name == "" {
+			continue
+		}
+
+		t, ok := conditionTypes[name]
+		if !ok {
+			return fmt.Errorf("[ERR] Gzip (%s) references cache_condition (%s), which is not a declared condition", gf["name"], name)
+		}
+		if t != "CACHE" {
+			return fmt.Errorf("[ERR] Gzip (%s) references cache_condition (%s), which must be a CACHE condition, not %s", gf["name"], name, t)
+		}
+	}
+
+	return nil
+}
+
+// validateDefaultLogCondition checks that default_log_condition, if set, is
+// declared in the condition set with the RESPONSE type.
+func validateDefaultLogCondition(d *schema.ResourceData) error {
+	name := d.Get("default_log_condition").(string)
+	if name == "" {
+		return nil
+	}
+
+	for _, cRaw := range conditionSet(d).List() {
+		cf := cRaw.(map[string]interface{})
+		if cf["name"].(string) != name {
+			continue
+		}
+		if t := cf["type"].(string); t != "RESPONSE" {
+			return fmt.Errorf("[ERR] default_log_condition (%s) must be a RESPONSE condition, not %s", name, t)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("[ERR] default_log_condition (%s) is not a declared condition", name)
+}
+
+// preserveDefaultLogCondition clears the response_condition of refreshed
+// logging endpoints which inherited it from default_log_condition, so that
+// endpoints configured without one don't show a change.
+func preserveDefaultLogCondition(ll []map[string]interface{}, configured *schema.Set, defaultLogCondition string) {
+	if defaultLogCondition == "" {
+		return
+	}
+
+	inheriting := make(map[string]bool)
+	for _, lRaw := range configured.List() {
+		lf := lRaw.(map[string]interface{})
+		if lf["response_condition"].(string) == "" {
+			inheriting[lf["name"].(string)] = true
+		}
+	}
+
+	for _, l := range ll {
+		name, _ := l["name"].(string)
+		if inheriting[name] && l["response_condition"] == defaultLogCondition {
+			delete(l, "response_condition")
+		}
+	}
+}
+
+// loggingFormatDirectives are the Apache-style directives Fastly accepts in a
+// logging format, and loggingFormatArgumentDirectives those of them which
+// need an argument in braces, such as %{User-Agent}i.
+const (
+	loggingFormatDirectives         = "aAbBCDefhHiIlmnoOpPqrstTuUvVX"
+	loggingFormatArgumentDirectives = "CeinoV"
+)
+
+// validateLoggingFormat checks the directives of a logging format, warning
+// about unknown directives, unbalanced braces and %{...}V directives which
+// don't reference a known VCL variable. Fastly accepts formats this can't
+// fully check, so problems are warnings rather than errors.
+func validateLoggingFormat(v interface{}, k string) (ws []string, es []error) {
+	format := v.(string)
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		start := i
+
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+
+		// Skip the status code conditions and < or > modifiers, as in %>s
+		for i < len(format) && strings.IndexByte("<>!,0123456789", format[i]) >= 0 {
+			i++
+		}
+
+		var arg string
+		hasArg := false
+		if i < len(format) && format[i] == '{' {
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				ws = append(ws, fmt.Sprintf("%q has an unbalanced { in %q", k, format[start:]))
+				return
+			}
+			arg = format[i+1 : i+end]
+			hasArg = true
+			i += end + 1
+		}
+
+		if i >= len(format) {
+			ws = append(ws, fmt.Sprintf("%q ends with an incomplete directive %q", k, format[start:]))
+			return
+		}
+
+		directive := format[start : i+1]
+		switch c := format[i]; {
+		case strings.IndexByte(loggingFormatDirectives, c) < 0:
+			ws = append(ws, fmt.Sprintf("%q has an unknown directive %q", k, directive))
+		case strings.IndexByte(loggingFormatArgumentDirectives, c) >= 0 && !hasArg:
+			ws = append(ws, fmt.Sprintf("%q directive %q needs an argument in braces", k, directive))
+		case c == 'V' && arg != "now" && !conditionVariablePattern.MatchString(arg):
+			ws = append(ws, fmt.Sprintf("%q directive %q does not reference a known VCL variable", k, directive))
+		}
+	}
+	return
+}
+
+// validateLoggingPeriod checks that a logging endpoint's period, in seconds,
+// is positive.
+func validateLoggingPeriod(v interface{}, k string) (ws []string, es []error) {
+	if period := v.(int); period < 1 {
+		es = append(es, fmt.Errorf(
+			"%q must be a positive number of seconds; found: %d", k, period))
+	}
+	return
+}
+
+// validateLoggingGzipLevel checks that a logging endpoint's gzip_level is a
+// valid compression level, from 0 for none to 9.
+func validateLoggingGzipLevel(v interface{}, k string) (ws []string, es []error) {
+	if level := v.(int); level < 0 || level > 9 {
+		es = append(es, fmt.Errorf(
+			"%q must be between 0 and 9; found: %d", k, level))
+	}
+	return
+}
+
+// loggingEndpointConcurrency bounds the number of logging endpoint calls
+// applyLoggingEndpoints makes at once.
+const loggingEndpointConcurrency = 4
+
+// loggingEndpointRateLimitRetries is the number of times a call rejected by
+// Fastly's rate limit is retried, waiting loggingEndpointRateLimitBackoff
+// before the first retry and twice as long before each one after it.
+const loggingEndpointRateLimitRetries = 5
+
+var loggingEndpointRateLimitBackoff = 1 * time.Second
+
+// applyLoggingEndpoints calls f for each of the logging endpoints, with at
+// most loggingEndpointConcurrency calls in flight. Every endpoint is tried
+// even when some fail. The failures are returned together, ordered by
+// endpoint name, along with the endpoints which were applied, so a partly
+// applied version is clear from the error. kind and action describe the
+// calls in it.
+func applyLoggingEndpoints(kind, action string, endpoints []interface{}, f func(map[string]interface{}) error) error {
+	type result struct {
+		name string
+		err  error
+	}
+
+	work := make(chan map[string]interface{})
+	results := make(chan result, len(endpoints))
+
+	var wg sync.WaitGroup
+	for i := 0; i < loggingEndpointConcurrency && i < len(endpoints); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ef := range work {
+				results <- result{
+					name: ef["name"].(string),
+					err:  retryLoggingEndpointRateLimit(func() error { return f(ef) }),
+				}
+			}
+		}()
+	}
+	for _, eRaw := range endpoints {
+		work <- eRaw.(map[string]interface{})
+	}
+	close(work)
+	wg.Wait()
+	close(results)
+
+	failed := make(map[string]error)
+	var failedNames, applied []string
+	for r := range results {
+		if r.err != nil {
+			failed[r.name] = r.err
+			failedNames = append(failedNames, r.name)
+		} else {
+			applied = append(applied, r.name)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	sort.Strings(failedNames)
+	sort.Strings(applied)
+
+	var errs *multierror.Error
+	for _, name := range failedNames {
+		errs = multierror.Append(errs, fmt.Errorf("%s endpoint %q: %s", kind, name, failed[name]))
+	}
+
+	appliedMsg := "none"
+	if len(applied) > 0 {
+		appliedMsg = strings.Join(applied, ", ")
+	}
+	return fmt.Errorf("[ERR] Error %s %d of %d %s endpoints, the version was left inactive. Endpoints applied: %s. %s", action, len(failed), len(endpoints), kind, appliedMsg, errs)
+}
+
+// retryLoggingEndpointRateLimit calls f, and calls it again with exponential
+// backoff while Fastly rejects it with 429 Too Many Requests.
+func retryLoggingEndpointRateLimit(f func() error) error {
+	backoff := loggingEndpointRateLimitBackoff
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if httpErr, ok := err.(*gofastly.HTTPError); !ok || httpErr.StatusCode != 429 || attempt == loggingEndpointRateLimitRetries {
+			return err
+		}
+
+		log.Printf("[DEBUG] Fastly rate limit reached, retrying in %s", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// loggingEndpointChanges returns the logging endpoints of the set at key to
+// remove and to add. Endpoints which inherit default_log_condition are
+// unchanged in the set when only the default changes, so they are included in
+// both to be recreated with it.
+func loggingEndpointChanges(d *schema.ResourceData, key string) (remove, add []interface{}) {
+	o, n := d.GetChange(key)
+	if o == nil {
+		o = new(schema.Set)
+	}
+	if n == nil {
+		n = new(schema.Set)
+	}
+
+	ols := o.(*schema.Set)
+	nls := n.(*schema.Set)
+
+	remove = ols.Difference(nls).List()
+	add = nls.Difference(ols).List()
+
+	if d.HasChange("default_log_condition") {
+		for _, lRaw := range ols.Intersection(nls).List() {
+			if lRaw.(map[string]interface{})["response_condition"].(string) == "" {
+				remove = append(remove, lRaw)
+				add = append(add, lRaw)
+			}
+		}
+	}
+
+	return remove, add
+}
+
+// loggingEndpointResource returns the schema of a logging endpoint of the
+// given kind: the name, format and response_condition every endpoint has,
+// along with the fields specific to the kind.
+func loggingEndpointResource(kind string, fields map[string]*schema.Schema) *schema.Resource {
+	s := map[string]*schema.Schema{
+		"name": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("A unique name to identify this %s endpoint", kind),
+		},
+		"format": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "%h %l %u %t %r %>s",
+			Description:  "Apache-style string or VCL variables to use for log formatting",
+			ValidateFunc: validateLoggingFormat,
+		},
+		"response_condition": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name of a RESPONSE Condition which must be met for a request to be logged",
+		},
+	}
+	for k, v := range fields {
+		s[k] = v
+	}
+
+	return &schema.Resource{Schema: s}
+}
+
+// s3LoggingResource returns the schema of an S3 logging endpoint.
+func s3LoggingResource() *schema.Resource {
+	return loggingEndpointResource("S3", map[string]*schema.Schema{
+		// required fields
+		"bucket_name": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The name of the bucket in which to store the logs",
+		},
+		"s3_access_key": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			DefaultFunc: schema.EnvDefaultFunc("FASTLY_S3_ACCESS_KEY", ""),
+			Description: "The AWS access key of a user allowed to write to the bucket",
+		},
+		"s3_secret_key": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			DefaultFunc: schema.EnvDefaultFunc("FASTLY_S3_SECRET_KEY", ""),
+			Description: "The AWS secret key of a user allowed to write to the bucket",
+		},
+		// optional fields
+		"path": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The path to store the logs under in the bucket",
+			StateFunc:   normalizeS3LoggingPath,
+		},
+		"period": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      3600,
+			Description:  "How frequently, in seconds, the logs are written to the bucket",
+			ValidateFunc: validateLoggingPeriod,
+		},
+		"gzip_level": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			Description:  "The gzip compression level of the logs, from 0 (no compression) to 9",
+			ValidateFunc: validateLoggingGzipLevel,
+		},
+		"timestamp_format": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "%Y-%m-%dT%H:%M:%S.000",
+			Description: "strftime specified timestamp formatting",
+		},
+	})
+}
+
+// hashS3Logging hashes an S3 logging endpoint with its path normalized, so
+// that paths which differ only in their leading or trailing slash, as they
+// are read back from Fastly, hash the same.
+func hashS3Logging(v interface{}) int {
+	m := make(map[string]interface{})
+	for k, v := range v.(map[string]interface{}) {
+		m[k] = v
+	}
+	if path, ok := m["path"]; ok {
+		m["path"] = normalizeS3LoggingPath(path)
+	}
+
+	return schema.HashResource(s3LoggingResource())(m)
+}
+
+// normalizeS3LoggingPath normalizes the path of an S3 logging endpoint to
+// start and end with a slash, as Fastly may add or strip either. An empty path
+// is left empty, so that Fastly's default applies.
+func normalizeS3LoggingPath(v interface{}) string {
+	path, _ := v.(string)
+	if path == "" {
+		return ""
+	}
+
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "/"
+	}
+	return "/" + path + "/"
+}
+
+// syslogResource returns the schema of a Syslog logging endpoint.
+func syslogResource() *schema.Resource {
+	return loggingEndpointResource("Syslog", map[string]*schema.Schema{
+		// required fields
+		"address": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The address of the Syslog log destination",
+		},
+		// optional fields
+		"port": &schema.Schema{
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Default:     514,
+			Description: "The port of the Syslog log destination",
+		},
+		"token": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "A token to prefix each log line with",
+		},
+		"use_tls": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Whether to send the logs over TLS",
+		},
+		"tls_ca_cert": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			StateFunc:   normalizeSyslogCert,
+			Description: "The PEM encoded CA certificate used to verify the Syslog log destination",
+		},
+	})
+}
+
+// hashSyslog hashes a Syslog logging endpoint with its tls_ca_cert
+// normalized, so that certificates which differ only in whitespace, as they
+// are read back from Fastly, hash the same.
+func hashSyslog(v interface{}) int {
+	m := make(map[string]interface{})
+	for k, v := range v.(map[string]interface{}) {
+		m[k] = v
+	}
+	if cert, ok := m["tls_ca_cert"]; ok {
+		m["tls_ca_cert"] = normalizeSyslogCert(cert)
+	}
+
+	return schema.HashResource(syslogResource())(m)
+}
+
+// normalizeSyslogCert normalizes the whitespace of a PEM certificate: line
+// endings are converted to newlines, the whitespace surrounding each line is
+// dropped, as are blank lines and the whitespace around the certificate.
+func normalizeSyslogCert(v interface{}) string {
+	cert, _ := v.(string)
+
+	var lines []string
+	for _, line := range strings.Split(strings.Replace(cert, "\r\n", "\n", -1), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// validateLoggingConditions checks that every response_condition referenced
+// by the logging endpoints in the set at key is declared in the condition set
+// with the RESPONSE type. kind names the endpoints in errors.
+func validateLoggingConditions(d *schema.ResourceData, key, kind string) error {
+	conditionTypes := make(map[string]string)
+	for _, cRaw := range conditionSet(d).List() {
+		cf := cRaw.(map[string]interface{})
+		conditionTypes[cf["name"].(string)] = cf["type"].(string)
+	}
+
+	for _, lRaw := range d.Get(key).(*schema.Set).List() {
+		lf := lRaw.(map[string]interface{})
+		name := lf["response_condition"].(string)
+		if name == "" {
+			continue
+		}
+
+		t, ok := conditionTypes[name]
+		if !ok {
+			return fmt.Errorf("[ERR] %s (%s) references response_condition (%s), which is not a declared condition", kind, lf["name"], name)
+		}
+		if t != "RESPONSE" {
+			return fmt.Errorf("[ERR] %s (%s) references response_condition (%s), which must be a RESPONSE condition, not %s", kind, lf["name"], name, t)
+		}
+	}
+
+	return nil
+}
+
+// fastlyValidationResponse is a Fastly version validation response. go-fastly
+// only decodes its status and msg, discarding the errors and warnings.
+type fastlyValidationResponse struct {
+	Status   string        `json:"status"`
+	Msg      string        `json:"msg"`
+	Errors   []interface{} `json:"errors"`
+	Warnings []interface{} `json:"warnings"`
+}
+
+// validateServiceVersionStrict validates a version like ValidateVersion, but
+// also fails it when Fastly returns any warnings.
+func validateServiceVersionStrict(conn *gofastly.Client, id, version string) (bool, string, error) {
+	resp, err := conn.Get(fmt.Sprintf("/service/%s/version/%s/validate", id, version), nil)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+
+	return checkServiceVersionValidation(resp.Body)
+}
+
+// checkServiceVersionValidation reads a version validation response. The
+// version is only valid if it has neither errors nor warnings, and the message
+// lists all of them.
+func checkServiceVersionValidation(body io.Reader) (bool, string, error) {
+	var r fastlyValidationResponse
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return false, "", fmt.Errorf("Error decoding validation: %s", err)
+	}
+
+	var problems []string
+	if r.Msg != "" {
+		problems = append(problems, r.Msg)
+	}
+	for _, e := range r.Errors {
+		problems = append(problems, "error: "+validationMessage(e))
+	}
+	for _, w := range r.Warnings {
+		problems = append(problems, "warning: "+validationMessage(w))
+	}
+
+	valid := r.Status == "ok" && len(r.Errors) == 0 && len(r.Warnings) == 0
+	return valid, strings.Join(problems, "; "), nil
+}
+
+// validationMessage returns the text of a validation error or warning, which
+// Fastly returns either as a string or as an object with a message.
+func validationMessage(v interface{}) string {
+	switch m := v.(type) {
+	case string:
+		return m
+	case map[string]interface{}:
+		for _, k := range []string{"message", "msg", "text"} {
+			if text, ok := m[k].(string); ok {
+				return text
+			}
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// readDomainCNAMEStatuses checks the DNS of every domain of a version with
+// the Fastly domain check API, which go-fastly does not wrap.
+func readDomainCNAMEStatuses(conn *gofastly.Client, id, version string) (map[string]string, error) {
+	resp, err := conn.Get(fmt.Sprintf("/service/%s/version/%s/domain/check_all", id, version), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return checkDomainCNAMEStatuses(resp.Body)
+}
+
+// checkDomainCNAMEStatuses reads a domain check response, which holds a
+// [domain, current CNAME, success] triple for each domain, into the
+// cname_status of each domain name. A domain is ok if it points at Fastly,
+// missing if it has no CNAME, and incorrect if it points elsewhere.
+func checkDomainCNAMEStatuses(body io.Reader) (map[string]string, error) {
+	var r [][]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("Error decoding domain check: %s", err)
+	}
+
+	statuses := make(map[string]string, len(r))
+	for _, check := range r {
+		if len(check) < 3 {
+			return nil, fmt.Errorf("Error decoding domain check: expected 3 fields, got %d", len(check))
+		}
+
+		var domain struct {
+			Name string `json:"name"`
+		}
+		var cname string
+		var success bool
+		if err := json.Unmarshal(check[0], &domain); err != nil {
+			return nil, fmt.Errorf("Error decoding domain check: %s", err)
+		}
+		// The current CNAME is null when the domain has none
+		if err := json.Unmarshal(check[1], &cname); err != nil && string(check[1]) != "null" {
+			return nil, fmt.Errorf("Error decoding domain check for %q: %s", domain.Name, err)
+		}
+		if err := json.Unmarshal(check[2], &success); err != nil {
+			return nil, fmt.Errorf("Error decoding domain check for %q: %s", domain.Name, err)
+		}
+
+		switch {
+		case success:
+			statuses[domain.Name] = "ok"
+		case cname == "":
+			statuses[domain.Name] = "missing"
+		default:
+			statuses[domain.Name] = "incorrect"
+		}
+	}
+
+	return statuses, nil
+}
+
+// fastlyStatsResponse is the part of a Fastly historical stats API response
+// which is summarised into stats.
+type fastlyStatsResponse struct {
+	Status string `json:"status"`
+	Msg    string `json:"msg"`
+	Data   []struct {
+		Requests int64 `json:"requests"`
+		Errors   int64 `json:"errors"`
+		Hits     int64 `json:"hits"`
+		Miss     int64 `json:"miss"`
+	} `json:"data"`
+}
+
+// readServiceStats reads the stats of a service over the last day from the
+// Fastly historical stats API, which go-fastly does not wrap.
+func readServiceStats(conn *gofastly.Client, id string) (map[string]interface{}, error) {
+	resp, err := conn.Get("/stats/service/"+id, &gofastly.RequestOptions{
+		Params: map[string]string{
+			"from": "1 day ago",
+			"by":   "hour",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return summarizeServiceStats(resp.Body)
+}
+
+// summarizeServiceStats totals the requests and errors of each period in a
+// stats response, and works out the hit ratio over all of them.
+func summarizeServiceStats(body io.Reader) (map[string]interface{}, error) {
+	var r fastlyStatsResponse
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("Error decoding stats: %s", err)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("Error reading stats: %s", r.Msg)
+	}
+
+	var requests, errs, hits, miss int64
+	for _, p := range r.Data {
+		requests += p.Requests
+		errs += p.Errors
+		hits += p.Hits
+		miss += p.Miss
+	}
+
+	var hitRatio float64
+	if hits+miss > 0 {
+		hitRatio = float64(hits) / float64(hits+miss)
+	}
+
+	return map[string]interface{}{
+		"requests":  strconv.FormatInt(requests, 10),
+		"errors":    strconv.FormatInt(errs, 10),
+		"hit_ratio": strconv.FormatFloat(hitRatio, 'f', 4, 64),
+	}, nil
+}
+
+// serviceV1ReadVersion returns the version the Service is read from, given
+// its active version and the most recent version Terraform built. That is the
+// built version if it is newer, unless hold is set and a version is active.
+func serviceV1ReadVersion(active, cloned string, hold bool) string {
+	if !isNewerVersion(cloned, active) {
+		return active
+	}
+	if hold && isNewerVersion(active, "0") {
+		return active
+	}
+	return cloned
+}
+
+// isNewerVersion reports whether version a is a later version number than b.
+// An empty or unparseable a is never newer, and any version is newer than an
+// empty b.
+func isNewerVersion(a, b string) bool {
+	an, err := strconv.Atoi(a)
+	if err != nil {
+		return false
+	}
+
+	bn, err := strconv.Atoi(b)
+	if err != nil {
+		return true
+	}
+
+	return an > bn
+}
+
+// joinGzipSet joins the members of a gzip content_types or extensions set
+// into the space separated form the Fastly API expects. Members are sorted so
+// the value sent to Fastly does not depend on the order they were written in.
+func joinGzipSet(s *schema.Set) string {
+	var l []string
+	for _, v := range s.List() {
+		l = append(l, v.(string))
+	}
+	sort.Strings(l)
+	return strings.Join(l, " ")
+}
+
+// gzipContentTypeWildcards lists the content types a wildcard in a gzip
+// rule's content_types expands to. Fastly matches content types exactly, so
+// wildcards are expanded before they are sent.
+var gzipContentTypeWildcards = map[string][]string{
+	"text/*": []string{
+		"text/css",
+		"text/csv",
+		"text/html",
+		"text/javascript",
+		"text/markdown",
+		"text/plain",
+		"text/xml",
+	},
+	"application/*": []string{
+		"application/atom+xml",
+		"application/javascript",
+		"application/json",
+		"application/ld+json",
+		"application/manifest+json",
+		"application/rss+xml",
+		"application/vnd.ms-fontobject",
+		"application/x-font-ttf",
+		"application/x-javascript",
+		"application/xhtml+xml",
+		"application/xml",
+	},
+	"font/*": []string{
+		"font/eot",
+		"font/opentype",
+		"font/otf",
+		"font/ttf",
+	},
+	"image/*": []string{
+		"image/svg+xml",
+		"image/vnd.microsoft.icon",
+		"image/x-icon",
+	},
+}
+
+// expandGzipContentTypes replaces each wildcard in content types with the
+// content types it stands for.
+func expandGzipContentTypes(s *schema.Set) (*schema.Set, error) {
+	expanded := schema.NewSet(schema.HashString, nil)
+	for _, v := range s.List() {
+		t := v.(string)
+		if !strings.Contains(t, "*") {
+			expanded.Add(t)
+			continue
+		}
+
+		types, ok := gzipContentTypeWildcards[t]
+		if !ok {
+			var known []string
+			for w := range gzipContentTypeWildcards {
+				known = append(known, w)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("[ERR] Gzip content type (%s) is not a supported wildcard, must be one of %s", t, strings.Join(known, ", "))
+		}
+		for _, e := range types {
+			expanded.Add(e)
+		}
+	}
+
+	return expanded, nil
+}
+
+// validateGzipContentTypes checks that every wildcard in a gzip rule's
+// content_types is one which can be expanded.
+func validateGzipContentTypes(d *schema.ResourceData) error {
+	for _, gRaw := range d.Get("gzip").(*schema.Set).List() {
+		gf := gRaw.(map[string]interface{})
+		if _, err := expandGzipContentTypes(gf["content_types"].(*schema.Set)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collapseGzipContentTypes restores the wildcards configured in content_types
+// on refreshed gzip rules. Fastly only stores the expanded content types, so
+// a rule is given back a configured wildcard when it has every content type
+// the wildcard expands to. Content types configured alongside a wildcard
+// which covers them are kept as well.
+func collapseGzipContentTypes(gl []map[string]interface{}, configured *schema.Set) {
+	configuredTypes := make(map[string]*schema.Set)
+	for _, gRaw := range configured.List() {
+		gf := gRaw.(map[string]interface{})
+		configuredTypes[gf["name"].(string)] = gf["content_types"].(*schema.Set)
+	}
+
+	for _, g := range gl {
+		remote, ok := g["content_types"].(*schema.Set)
+		if !ok {
+			continue
+		}
+		name, _ := g["name"].(string)
+		want, ok := configuredTypes[name]
+		if !ok {
+			continue
+		}
+
+		collapsed := schema.CopySet(remote)
+		for _, v := range want.List() {
+			types, ok := gzipContentTypeWildcards[v.(string)]
+			if !ok {
+				continue
+			}
+
+			covered := true
+			for _, t := range types {
+				if !remote.Contains(t) {
+					covered = false
+					break
+				}
+			}
+			if !covered {
+				continue
+			}
+
+			for _, t := range types {
+				if !want.Contains(t) {
+					collapsed.Remove(t)
+				}
+			}
+			collapsed.Add(v)
+		}
+
+		g["content_types"] = collapsed
+	}
+}
+
+// splitGzipList splits the space separated content_types or extensions value
+// returned by Fastly into sorted members. Repeated or surrounding whitespace,
+// including the " " sentinel used on create, yields no empty members.
+func splitGzipList(v string) []interface{} {
+	f := strings.Fields(v)
+	sort.Strings(f)
+
+	var l []interface{}
+	for _, e := range f {
+		l = append(l, e)
+	}
+	return l
+}
+
+// updateForceTLS creates or removes the request setting and response header
+// which implement force_tls on the given, unlocked, version.
+func updateForceTLS(conn *gofastly.Client, service, version string, enabled bool) error {
+	if !enabled {
+		log.Printf("[DEBUG] Fastly force_tls Request Setting Removal: %s", fastlyForceTLSRequestSettingName)
+		err := conn.DeleteRequestSetting(&gofastly.DeleteRequestSettingInput{
+			Service: service,
+			Version: version,
+			Name:    fastlyForceTLSRequestSettingName,
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] Fastly force_tls Header Removal: %s", fastlyForceTLSHeaderName)
+		return conn.DeleteHeader(&gofastly.DeleteHeaderInput{
+			Service: service,
+			Version: version,
+			Name:    fastlyForceTLSHeaderName,
+		})
+	}
+
+	rsOpts := gofastly.CreateRequestSettingInput{
+		Service:  service,
+		Version:  version,
+		Name:     fastlyForceTLSRequestSettingName,
+		ForceSSL: gofastly.Compatibool(true),
+	}
+
+	log.Printf("[DEBUG] Fastly force_tls Request Setting Addition opts: %#v", rsOpts)
+	if _, err := conn.CreateRequestSetting(&rsOpts); err != nil {
+		return err
+	}
+
+	hOpts := gofastly.CreateHeaderInput{
+		Service:     service,
+		Version:     version,
+		Name:        fastlyForceTLSHeaderName,
+		Action:      gofastly.HeaderActionSet,
+		Type:        gofastly.HeaderTypeResponse,
+		Destination: "http.Strict-Transport-Security",
+		Source:      fmt.Sprintf(`"max-age=%d"`, fastlyForceTLSHSTSMaxAge),
+		Priority:    100,
+	}
+
+	log.Printf("[DEBUG] Fastly force_tls Header Addition opts: %#v", hOpts)
+	_, err := conn.CreateHeader(&hOpts)
+	return err
+}
+
+// updateMaintenanceMode removes the condition and response object which
+// implement maintenance_mode if it was enabled, and creates them with the
+// current page if it is enabled, on the given, unlocked, version.
+func updateMaintenanceMode(conn *gofastly.Client, service, version string, wasEnabled, enabled bool, page string) error {
+	if wasEnabled {
+		// The response object references the condition, so it is removed first
+		log.Printf("[DEBUG] Fastly maintenance_mode Response Object Removal: %s", fastlyMaintenanceModeName)
+		err := conn.DeleteResponseObject(&gofastly.DeleteResponseObjectInput{
+			Service: service,
+			Version: version,
+			Name:    fastlyMaintenanceModeName,
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] Fastly maintenance_mode Condition Removal: %s", fastlyMaintenanceModeName)
+		err = conn.DeleteCondition(&gofastly.DeleteConditionInput{
+			Service: service,
+			Version: version,
+			Name:    fastlyMaintenanceModeName,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	cOpts := gofastly.CreateConditionInput{
+		Service:   service,
+		Version:   version,
+		Name:      fastlyMaintenanceModeName,
+		Statement: "true",
+		Type:      "REQUEST",
+		Priority:  fastlyMaintenanceModeConditionPriority,
+	}
+
+	log.Printf("[DEBUG] Fastly maintenance_mode Condition Addition opts: %#v", cOpts)
+	if _, err := conn.CreateCondition(&cOpts); err != nil {
+		return err
+	}
+
+	roOpts := gofastly.CreateResponseObjectInput{
+		Service:          service,
+		Version:          version,
+		Name:             fastlyMaintenanceModeName,
+		Status:           503,
+		Response:         "Service Unavailable",
+		Content:          page,
+		ContentType:      "text/html",
+		RequestCondition: fastlyMaintenanceModeName,
+	}
+
+	log.Printf("[DEBUG] Fastly maintenance_mode Response Object Addition opts: %#v", roOpts)
+	_, err := conn.CreateResponseObject(&roOpts)
+	return err
+}
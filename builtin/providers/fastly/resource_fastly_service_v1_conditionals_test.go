@@ -0,0 +1,765 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenConditions(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.Condition
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.Condition{
+				&gofastly.Condition{
+					Name:      "ok response",
+					Statement: "beresp.status == 200",
+					Type:      "CACHE",
+					Priority:  10,
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":      "ok response",
+					"statement": "beresp.status == 200",
+					"type":      "CACHE",
+					"priority":  10,
+				},
+			},
+		},
+		// the maintenance_mode condition is not a user declared condition
+		{
+			remote: []*gofastly.Condition{
+				&gofastly.Condition{
+					Name:      fastlyMaintenanceModeName,
+					Statement: "true",
+					Type:      "REQUEST",
+					Priority:  1,
+				},
+				&gofastly.Condition{
+					Name:      "ok response",
+					Statement: "beresp.status == 200",
+					Type:      "CACHE",
+					Priority:  10,
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":      "ok response",
+					"statement": "beresp.status == 200",
+					"type":      "CACHE",
+					"priority":  10,
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenConditions(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ConditionStatement(t *testing.T) {
+	cases := []struct {
+		condition map[string]interface{}
+		statement string
+		expectErr bool
+	}{
+		{
+			condition: map[string]interface{}{
+				"name":      "ok response",
+				"statement": "beresp.status == 200",
+			},
+			statement: "beresp.status == 200",
+		},
+		{
+			condition: map[string]interface{}{
+				"name": "html from api",
+				"expressions": []interface{}{
+					"req.http.host == \"api.example.com\"",
+					"req.url ~ \".html$\"",
+				},
+				"operator": "",
+			},
+			statement: "(req.http.host == \"api.example.com\") && (req.url ~ \".html$\")",
+		},
+		{
+			condition: map[string]interface{}{
+				"name": "error response",
+				"expressions": []interface{}{
+					"beresp.status >= 500",
+					" beresp.status == 404 ",
+				},
+				"operator": "or",
+			},
+			statement: "(beresp.status >= 500) || (beresp.status == 404)",
+		},
+		{
+			condition: map[string]interface{}{
+				"name": "typo",
+				"expressions": []interface{}{
+					"request.url ~ \".html$\"",
+				},
+				"operator": "and",
+			},
+			expectErr: true,
+		},
+		{
+			condition: map[string]interface{}{
+				"name":      "both",
+				"statement": "beresp.status == 200",
+				"expressions": []interface{}{
+					"beresp.status == 200",
+				},
+				"operator": "and",
+			},
+			expectErr: true,
+		},
+		{
+			condition: map[string]interface{}{
+				"name":      "neither",
+				"statement": "",
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		out, err := conditionStatement(c.condition)
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got statement: %s", i, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if out != c.statement {
+			t.Fatalf("%d: Error matching:\nexpected: %s\ngot: %s", i, c.statement, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_PreserveConditionExpressions(t *testing.T) {
+	d := resourceServiceV1().TestResourceData()
+	err := d.Set("condition", []interface{}{
+		map[string]interface{}{
+			"name":        "error response",
+			"expressions": []interface{}{"beresp.status >= 500", "beresp.status == 404"},
+			"operator":    "or",
+			"type":        "CACHE",
+			"priority":    10,
+		},
+		map[string]interface{}{
+			"name":        "changed",
+			"expressions": []interface{}{"beresp.status == 200"},
+			"type":        "CACHE",
+			"priority":    10,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error setting conditions: %s", err)
+	}
+
+	cl := flattenConditions([]*gofastly.Condition{
+		&gofastly.Condition{
+			Name:      "error response",
+			Statement: "(beresp.status >= 500) || (beresp.status == 404)",
+			Type:      "CACHE",
+			Priority:  10,
+		},
+		&gofastly.Condition{
+			Name:      "changed",
+			Statement: "beresp.status == 301",
+			Type:      "CACHE",
+			Priority:  10,
+		},
+	})
+	preserveConditionExpressions(cl, d.Get("condition").(*schema.Set))
+
+	expected := []map[string]interface{}{
+		map[string]interface{}{
+			"name":        "error response",
+			"expressions": []interface{}{"beresp.status >= 500", "beresp.status == 404"},
+			"operator":    "or",
+			"type":        "CACHE",
+			"priority":    10,
+		},
+		map[string]interface{}{
+			"name":      "changed",
+			"statement": "beresp.status == 301",
+			"type":      "CACHE",
+			"priority":  10,
+		},
+	}
+	if !reflect.DeepEqual(cl, expected) {
+		t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", expected, cl)
+	}
+}
+
+func TestFastlyServiceV1_DefaultConditions(t *testing.T) {
+	cases := []struct {
+		conditions []interface{}
+		priorities map[string]int
+		expectErr  bool
+	}{
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "api", "statement": "req.url ~ \"^/api\"", "type": "REQUEST", "priority": 5},
+				map[string]interface{}{"name": "static", "statement": "req.url ~ \"^/static\"", "type": "REQUEST", "priority": 20},
+				map[string]interface{}{"name": "ok response", "statement": "beresp.status == 200", "type": "CACHE", "priority": 50},
+				map[string]interface{}{"name": "everything else", "statement": "true", "type": "REQUEST", "default": true},
+			},
+			priorities: map[string]int{"everything else": 21},
+		},
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "everything else", "statement": "true", "type": "REQUEST", "default": true},
+				map[string]interface{}{"name": "any response", "statement": "true", "type": "CACHE", "default": true},
+			},
+			priorities: map[string]int{"everything else": 1, "any response": 1},
+		},
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "everything else", "statement": "true", "type": "REQUEST", "default": true},
+				map[string]interface{}{"name": "anything", "statement": "true", "type": "REQUEST", "default": true},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("condition", c.conditions); err != nil {
+			t.Fatalf("%d: error setting conditions: %s", i, err)
+		}
+
+		err := validateDefaultConditions(d)
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+
+		priorities := defaultConditionPriorities(d.Get("condition").(*schema.Set))
+		if !reflect.DeepEqual(priorities, c.priorities) {
+			t.Fatalf("%d: Error matching:\nexpected: %#v\ngot: %#v", i, c.priorities, priorities)
+		}
+	}
+}
+
+func TestFastlyServiceV1_PreserveDefaultConditions(t *testing.T) {
+	d := resourceServiceV1().TestResourceData()
+	err := d.Set("condition", []interface{}{
+		map[string]interface{}{
+			"name":      "everything else",
+			"statement": "true",
+			"type":      "REQUEST",
+			"priority":  10,
+			"default":   true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error setting conditions: %s", err)
+	}
+
+	cl := flattenConditions([]*gofastly.Condition{
+		&gofastly.Condition{
+			Name:      "everything else",
+			Statement: "true",
+			Type:      "REQUEST",
+			Priority:  21,
+		},
+		&gofastly.Condition{
+			Name:      "api",
+			Statement: "req.url ~ \"^/api\"",
+			Type:      "REQUEST",
+			Priority:  20,
+		},
+	})
+	preserveDefaultConditions(cl, d.Get("condition").(*schema.Set))
+
+	expected := []map[string]interface{}{
+		map[string]interface{}{
+			"name":      "everything else",
+			"statement": "true",
+			"type":      "REQUEST",
+			"priority":  10,
+			"default":   true,
+		},
+		map[string]interface{}{
+			"name":      "api",
+			"statement": "req.url ~ \"^/api\"",
+			"type":      "REQUEST",
+			"priority":  20,
+		},
+	}
+	if !reflect.DeepEqual(cl, expected) {
+		t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", expected, cl)
+	}
+}
+
+func TestFastlyServiceV1_ConditionsMap(t *testing.T) {
+	d := resourceServiceV1().TestResourceData()
+	if err := d.Set("condition", []interface{}{
+		map[string]interface{}{
+			"name":      "api",
+			"statement": "req.url ~ \"^/api/\"",
+			"type":      "REQUEST",
+			"priority":  10,
+		},
+	}); err != nil {
+		t.Fatalf("error setting conditions: %s", err)
+	}
+	block := d.Get("condition").(*schema.Set)
+
+	// A Condition moved from a block to the map is the same Condition
+	merged := mergeConditions(schema.NewSet(block.F, nil), map[string]interface{}{
+		"api": "req.url ~ \"^/api/\"",
+	}, "REQUEST", 10)
+	if !merged.Equal(block) {
+		t.Fatalf("Expected the map Condition to match the block:\nblock: %#v\nmap: %#v", block.List(), merged.List())
+	}
+
+	merged = mergeConditions(block, map[string]interface{}{
+		"static": "req.url ~ \"^/static/\"",
+		"images": "req.url ~ \"^/images/\"",
+	}, "REQUEST", 20)
+	if merged.Len() != 3 {
+		t.Fatalf("Expected 3 Conditions, got %d", merged.Len())
+	}
+
+	cl := []map[string]interface{}{
+		{"name": "api", "statement": "req.url ~ \"^/api/\"", "type": "REQUEST", "priority": 10},
+		{"name": "static", "statement": "req.url ~ \"^/static/\"", "type": "REQUEST", "priority": 20},
+		{"name": "images", "statement": "req.url ~ \"^/images/\"", "type": "REQUEST", "priority": 5},
+	}
+	rest, cm := splitConditions(cl, map[string]interface{}{
+		"static": "req.url ~ \"^/static/\"",
+		"images": "req.url ~ \"^/images/\"",
+	}, "REQUEST", 20)
+
+	// images has drifted from the map's priority, so it stays in the set
+	expectedRest := []map[string]interface{}{cl[0], cl[2]}
+	if !reflect.DeepEqual(rest, expectedRest) {
+		t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", expectedRest, rest)
+	}
+	expectedMap := map[string]interface{}{"static": "req.url ~ \"^/static/\""}
+	if !reflect.DeepEqual(cm, expectedMap) {
+		t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", expectedMap, cm)
+	}
+}
+
+func TestFastlyServiceV1_ValidateConditionNames(t *testing.T) {
+	cases := []struct {
+		conditions map[string]interface{}
+		expectErr  bool
+	}{
+		{conditions: map[string]interface{}{"static": "req.url ~ \"^/static/\""}, expectErr: false},
+		{conditions: map[string]interface{}{"api": "req.url ~ \"^/v2/\""}, expectErr: true},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("condition", []interface{}{
+			map[string]interface{}{
+				"name":      "api",
+				"statement": "req.url ~ \"^/api/\"",
+				"type":      "REQUEST",
+			},
+		}); err != nil {
+			t.Fatalf("%d: error setting conditions: %s", i, err)
+		}
+		if err := d.Set("conditions", c.conditions); err != nil {
+			t.Fatalf("%d: error setting conditions map: %s", i, err)
+		}
+
+		err := validateConditionNames(d)
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+
+	if _, es := validateConditionsMap(map[string]interface{}{fastlyMaintenanceModeName: "true"}, "conditions"); len(es) == 0 {
+		t.Fatalf("Expected the maintenance_mode Condition name to be reserved")
+	}
+}
+
+func TestFastlyServiceV1_ValidateGzipConditions(t *testing.T) {
+	cases := []struct {
+		conditions []interface{}
+		gzips      []interface{}
+		expectErr  bool
+	}{
+		{
+			conditions: []interface{}{},
+			gzips: []interface{}{
+				map[string]interface{}{"name": "all"},
+			},
+			expectErr: false,
+		},
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "ok response", "statement": "beresp.status == 200", "type": "CACHE", "priority": 10},
+			},
+			gzips: []interface{}{
+				map[string]interface{}{"name": "all", "cache_condition": "ok response"},
+			},
+			expectErr: false,
+		},
+		{
+			conditions: []interface{}{},
+			gzips: []interface{}{
+				map[string]interface{}{"name": "all", "cache_condition": "missing"},
+			},
+			expectErr: true,
+		},
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "is html", "statement": "req.url ~ \".html$\"", "type": "REQUEST", "priority": 10},
+			},
+			gzips: []interface{}{
+				map[string]interface{}{"name": "all", "cache_condition": "is html"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("condition", c.conditions); err != nil {
+			t.Fatalf("%d: error setting conditions: %s", i, err)
+		}
+		if err := d.Set("gzip", c.gzips); err != nil {
+			t.Fatalf("%d: error setting gzips: %s", i, err)
+		}
+
+		err := validateGzipConditions(d)
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_conditional_gzip(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1ConditionConfig_gzip(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1ConditionalAttributes(&service, name, "ok response", "CACHE"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "condition.#", "1"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "condition.831413158.statement", "beresp.status == 200"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gzip.#", "1"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gzip.3550404182.cache_condition", "ok response"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_conditional_default(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1ConditionConfig_default(name, domainName1, 20),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1ConditionPriority(&service, "everything else", 21),
+				),
+			},
+
+			// Raising the priority of another condition moves the default after it
+			resource.TestStep{
+				Config: testAccServiceV1ConditionConfig_default(name, domainName1, 30),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1ConditionPriority(&service, "everything else", 31),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_conditional_map(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1ConditionConfig_map(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1ConditionNames(&service, []string{"api", "static", "images", "server errors"}),
+					testAccCheckFastlyServiceV1ConditionPriority(&service, "static", 20),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "conditions.%", "3"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "condition.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1ConditionNames checks that the active version has
+// exactly the named Conditions.
+func testAccCheckFastlyServiceV1ConditionNames(service *gofastly.ServiceDetail, names []string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		conditionList, err := conn.ListConditions(&gofastly.ListConditionsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Conditions for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(conditionList) != len(names) {
+			return fmt.Errorf("Condition count mismatch, expected (%d), got (%d)", len(names), len(conditionList))
+		}
+
+		remote := make(map[string]bool)
+		for _, c := range conditionList {
+			remote[c.Name] = true
+		}
+		for _, n := range names {
+			if !remote[n] {
+				return fmt.Errorf("Condition (%s) not found on version (%s)", n, service.ActiveVersion.Number)
+			}
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1ConditionPriority checks the priority of the
+// named Condition on the active version.
+func testAccCheckFastlyServiceV1ConditionPriority(service *gofastly.ServiceDetail, name string, priority int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		c, err := conn.GetCondition(&gofastly.GetConditionInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+			Name:    name,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Condition (%s) for (%s), version (%s): %s", name, service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if c.Priority != priority {
+			return fmt.Errorf("Bad Condition priority, expected (%d), got (%d)", priority, c.Priority)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckFastlyServiceV1ConditionalAttributes(service *gofastly.ServiceDetail, name, condition, conditionType string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if service.Name != name {
+			return fmt.Errorf("Bad name, expected (%s), got (%s)", name, service.Name)
+		}
+
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		conditionList, err := conn.ListConditions(&gofastly.ListConditionsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Conditions for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(conditionList) != 1 {
+			return fmt.Errorf("Condition count mismatch, expected (1), got (%d)", len(conditionList))
+		}
+
+		if conditionList[0].Name != condition || conditionList[0].Type != conditionType {
+			return fmt.Errorf("Condition mismatch, expected (%s/%s), got (%s/%s)", condition, conditionType, conditionList[0].Name, conditionList[0].Type)
+		}
+
+		gzipsList, err := conn.ListGzips(&gofastly.ListGzipsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Gzips for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(gzipsList) != 1 {
+			return fmt.Errorf("Gzip count mismatch, expected (1), got (%d)", len(gzipsList))
+		}
+
+		if gzipsList[0].CacheCondition != condition {
+			return fmt.Errorf("Gzip cache condition mismatch, expected (%s), got (%s)", condition, gzipsList[0].CacheCondition)
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1ConditionConfig_gzip(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "ok response"
+    statement = "beresp.status == 200"
+    type      = "CACHE"
+  }
+
+  gzip {
+    name            = "gzip ok responses"
+    extensions      = ["css", "js", "html"]
+    cache_condition = "ok response"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1ConditionConfig_map(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "server errors"
+    statement = "resp.status >= 500"
+    type      = "RESPONSE"
+  }
+
+  conditions {
+    api    = "req.url ~ \"^/api/\""
+    static = "req.url ~ \"^/static/\""
+    images = "req.url ~ \"^/images/\""
+  }
+
+  conditions_priority = 20
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1ConditionConfig_default(name, domain string, priority int) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "api"
+    statement = "req.url ~ \"^/api\""
+    type      = "REQUEST"
+    priority  = %d
+  }
+
+  condition {
+    name      = "everything else"
+    statement = "true"
+    type      = "REQUEST"
+    default   = true
+  }
+
+  header {
+    destination       = "http.X-Route"
+    type              = "request"
+    action            = "set"
+    name              = "route api"
+    source            = "\"api\""
+    request_condition = "api"
+  }
+
+  header {
+    destination       = "http.X-Route"
+    type              = "request"
+    action            = "set"
+    name              = "route everything else"
+    source            = "\"default\""
+    request_condition = "everything else"
+  }
+
+  force_destroy = true
+}`, name, domain, priority)
+}
@@ -0,0 +1,180 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenDictionaries(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.Dictionary
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.Dictionary{
+				&gofastly.Dictionary{
+					ID:   "1234",
+					Name: "redirects",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name": "redirects",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenDictionaries(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateDictionaryReferences(t *testing.T) {
+	dictionaries := []interface{}{
+		map[string]interface{}{"name": "redirects"},
+	}
+
+	cases := []struct {
+		headers   []interface{}
+		expectErr bool
+	}{
+		{
+			headers: []interface{}{
+				map[string]interface{}{
+					"name":        "redirect target",
+					"action":      "set",
+					"type":        "request",
+					"destination": "http.X-Redirect",
+					"source":      "table.lookup(redirects, req.url.path)",
+				},
+			},
+			expectErr: false,
+		},
+		{
+			headers: []interface{}{
+				map[string]interface{}{
+					"name":         "rewrite host",
+					"action":       "regex",
+					"type":         "request",
+					"destination":  "http.host",
+					"source":       "req.http.host",
+					"regex":        "^www\\.",
+					"substitution": "table.lookup( redirects, \"www\" )",
+				},
+			},
+			expectErr: false,
+		},
+		{
+			headers: []interface{}{
+				map[string]interface{}{
+					"name":        "redirect target",
+					"action":      "set",
+					"type":        "request",
+					"destination": "http.X-Redirect",
+					"source":      "table.lookup(redirect, req.url.path)",
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("dictionary", dictionaries); err != nil {
+			t.Fatalf("%d: error setting dictionaries: %s", i, err)
+		}
+		if err := d.Set("header", c.headers); err != nil {
+			t.Fatalf("%d: error setting headers: %s", i, err)
+		}
+
+		err := validateDictionaryReferences(d)
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_dictionary_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1DictionaryConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Dictionary(&service, "redirects"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "dictionary.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1Dictionary checks that the named Dictionary is
+// present on the active version.
+func testAccCheckFastlyServiceV1Dictionary(service *gofastly.ServiceDetail, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		_, err := conn.GetDictionary(&gofastly.GetDictionaryInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+			Name:    name,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Dictionary (%s) for (%s), version (%s): %s", name, service.Name, service.ActiveVersion.Number, err)
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1DictionaryConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  dictionary {
+    name = "redirects"
+  }
+
+  header {
+    destination = "http.X-Redirect"
+    type        = "request"
+    action      = "set"
+    name        = "redirect target"
+    source      = "table.lookup(redirects, req.url.path)"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
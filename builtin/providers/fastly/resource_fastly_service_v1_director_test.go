@@ -0,0 +1,242 @@
+package fastly
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenDirectors(t *testing.T) {
+	cases := []struct {
+		remote   []*gofastly.Director
+		backends map[string][]string
+		local    []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.Director{
+				&gofastly.Director{
+					Name:    "mydirector",
+					Quorum:  75,
+					Type:    gofastly.DirectorTypeRandom,
+					Retries: 5,
+				},
+			},
+			backends: map[string][]string{
+				"mydirector": []string{"backend a", "backend b"},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":     "mydirector",
+					"quorum":   75,
+					"type":     1,
+					"retries":  5,
+					"backends": schema.NewSet(schema.HashString, []interface{}{"backend a", "backend b"}),
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenDirectors(c.remote, c.backends)
+		if len(out) != len(c.local) {
+			t.Fatalf("Expected %d directors, got %d", len(c.local), len(out))
+		}
+
+		for i, o := range out {
+			l := c.local[i]
+			for _, k := range []string{"name", "quorum", "type", "retries"} {
+				if o[k] != l[k] {
+					t.Fatalf("Director %s mismatch, expected: %#v, got: %#v", k, l[k], o[k])
+				}
+			}
+
+			if _, ok := o["comment"]; ok {
+				t.Fatalf("Expected empty comment to be pruned, got: %#v", o["comment"])
+			}
+
+			if !o["backends"].(*schema.Set).Equal(l["backends"]) {
+				t.Fatalf("Director backends mismatch, expected: %#v, got: %#v", l["backends"], o["backends"])
+			}
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateDirectorBackends(t *testing.T) {
+	backends := []interface{}{
+		map[string]interface{}{"name": "backend a", "address": "a.notadomain.com"},
+	}
+
+	cases := []struct {
+		directors []interface{}
+		expectErr bool
+	}{
+		{
+			directors: []interface{}{
+				map[string]interface{}{
+					"name":     "mydirector",
+					"backends": schema.NewSet(schema.HashString, []interface{}{"backend a"}),
+				},
+			},
+			expectErr: false,
+		},
+		{
+			directors: []interface{}{
+				map[string]interface{}{
+					"name":     "mydirector",
+					"backends": schema.NewSet(schema.HashString, []interface{}{"backend a", "missing"}),
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("backend", backends); err != nil {
+			t.Fatalf("%d: error setting backends: %s", i, err)
+		}
+		if err := d.Set("director", c.directors); err != nil {
+			t.Fatalf("%d: error setting directors: %s", i, err)
+		}
+
+		err := validateDirectorBackends(d)
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_directors_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1DirectorsConfig(name, domainName1, `"amazon docs", "developer"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1DirectorBackends(&service, "mydirector", []string{"amazon docs", "developer"}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "director.#", "1"),
+				),
+			},
+
+			// Adding a Backend to the Director must not recreate it
+			resource.TestStep{
+				Config: testAccServiceV1DirectorsConfig(name, domainName1, `"amazon docs", "developer", "status"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1DirectorBackends(&service, "mydirector", []string{"amazon docs", "developer", "status"}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "director.#", "1"),
+				),
+			},
+
+			// Removing a Backend from the Director must not recreate it
+			resource.TestStep{
+				Config: testAccServiceV1DirectorsConfig(name, domainName1, `"status"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1DirectorBackends(&service, "mydirector", []string{"status"}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "director.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1DirectorBackends checks that the named Director
+// is present on the active version with exactly the expected Backends.
+func testAccCheckFastlyServiceV1DirectorBackends(service *gofastly.ServiceDetail, director string, expected []string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		_, err := conn.GetDirector(&gofastly.GetDirectorInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+			Name:    director,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Director (%s) for (%s), version (%s): %s", director, service.Name, service.ActiveVersion.Number, err)
+		}
+
+		backendList, err := conn.ListBackends(&gofastly.ListBackendsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Backends for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		want := make(map[string]bool)
+		for _, b := range expected {
+			want[b] = true
+		}
+
+		for _, b := range backendList {
+			_, err := conn.GetDirectorBackend(&gofastly.GetDirectorBackendInput{
+				Service:  service.ID,
+				Version:  service.ActiveVersion.Number,
+				Director: director,
+				Backend:  b.Name,
+			})
+			if herr, ok := err.(*gofastly.HTTPError); err != nil && !(ok && herr.IsNotFound()) {
+				return fmt.Errorf("[ERR] Error looking up Backend (%s) of Director (%s): %s", b.Name, director, err)
+			}
+
+			member := err == nil
+			if member != want[b.Name] {
+				return fmt.Errorf("Director (%s) membership of Backend (%s) mismatch, expected (%t), got (%t)", director, b.Name, want[b.Name], member)
+			}
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1DirectorsConfig(name, domain, backends string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  backend {
+    address = "developer.fastly.com"
+    name    = "developer"
+  }
+
+  backend {
+    address = "status.fastly.com"
+    name    = "status"
+  }
+
+  director {
+    name     = "mydirector"
+    backends = [%s]
+  }
+
+  force_destroy = true
+}`, name, domain, backends)
+}
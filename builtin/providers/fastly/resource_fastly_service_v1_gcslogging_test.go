@@ -0,0 +1,587 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenGCSs(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.GCS
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.GCS{
+				&gofastly.GCS{
+					Name:            "gcs-endpoint",
+					User:            "logs@example.iam.gserviceaccount.com",
+					Bucket:          "fastly-logs",
+					SecretKey:       "secret",
+					Period:          3600,
+					GzipLevel:       9,
+					Format:          "%h %l %u %t %r %>s",
+					TimestampFormat: "%Y-%m-%dT%H:%M:%S.000",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":             "gcs-endpoint",
+					"email":            "logs@example.iam.gserviceaccount.com",
+					"bucket_name":      "fastly-logs",
+					"secret_key":       "secret",
+					"period":           3600,
+					"gzip_level":       9,
+					"format":           "%h %l %u %t %r %>s",
+					"timestamp_format": "%Y-%m-%dT%H:%M:%S.000",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenGCSs(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateGCSLoggingConditions(t *testing.T) {
+	endpoint := func(condition string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":               "gcs-endpoint",
+			"email":              "logs@example.iam.gserviceaccount.com",
+			"bucket_name":        "fastly-logs",
+			"secret_key":         "secret",
+			"response_condition": condition,
+		}
+	}
+
+	cases := []struct {
+		conditions []interface{}
+		gcs        []interface{}
+		expectErr  bool
+	}{
+		{
+			conditions: []interface{}{},
+			gcs:        []interface{}{endpoint("")},
+			expectErr:  false,
+		},
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "errors", "statement": "resp.status >= 500", "type": "RESPONSE", "priority": 10},
+			},
+			gcs:       []interface{}{endpoint("errors")},
+			expectErr: false,
+		},
+		{
+			conditions: []interface{}{},
+			gcs:        []interface{}{endpoint("missing")},
+			expectErr:  true,
+		},
+		{
+			conditions: []interface{}{
+				map[string]interface{}{"name": "is html", "statement": "req.url ~ \".html$\"", "type": "REQUEST", "priority": 10},
+			},
+			gcs:       []interface{}{endpoint("is html")},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("condition", c.conditions); err != nil {
+			t.Fatalf("%d: error setting conditions: %s", i, err)
+		}
+		if err := d.Set("gcslogging", c.gcs); err != nil {
+			t.Fatalf("%d: error setting gcslogging: %s", i, err)
+		}
+
+		err := validateLoggingConditions(d, "gcslogging", "GCS logging")
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateDefaultLogCondition(t *testing.T) {
+	conditions := []interface{}{
+		map[string]interface{}{"name": "not healthcheck", "statement": "req.url != \"/healthz\"", "type": "RESPONSE", "priority": 10},
+		map[string]interface{}{"name": "is html", "statement": "req.url ~ \".html$\"", "type": "REQUEST", "priority": 10},
+	}
+
+	cases := []struct {
+		defaultLogCondition string
+		expectErr           bool
+	}{
+		{defaultLogCondition: "", expectErr: false},
+		{defaultLogCondition: "not healthcheck", expectErr: false},
+		{defaultLogCondition: "missing", expectErr: true},
+		{defaultLogCondition: "is html", expectErr: true},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("condition", conditions); err != nil {
+			t.Fatalf("%d: error setting conditions: %s", i, err)
+		}
+		d.Set("default_log_condition", c.defaultLogCondition)
+
+		err := validateDefaultLogCondition(d)
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestFastlyServiceV1_PreserveDefaultLogCondition(t *testing.T) {
+	d := resourceServiceV1().TestResourceData()
+	err := d.Set("gcslogging", []interface{}{
+		map[string]interface{}{
+			"name":        "inherits",
+			"email":       "logs@example.iam.gserviceaccount.com",
+			"bucket_name": "fastly-logs",
+			"secret_key":  "secret",
+		},
+		map[string]interface{}{
+			"name":               "explicit",
+			"email":              "logs@example.iam.gserviceaccount.com",
+			"bucket_name":        "fastly-logs",
+			"secret_key":         "secret",
+			"response_condition": "not healthcheck",
+		},
+		map[string]interface{}{
+			"name":        "drifted",
+			"email":       "logs@example.iam.gserviceaccount.com",
+			"bucket_name": "fastly-logs",
+			"secret_key":  "secret",
+		},
+	})
+	if err != nil {
+		t.Fatalf("error setting gcslogging: %s", err)
+	}
+
+	ll := []map[string]interface{}{
+		map[string]interface{}{"name": "inherits", "response_condition": "not healthcheck"},
+		map[string]interface{}{"name": "explicit", "response_condition": "not healthcheck"},
+		map[string]interface{}{"name": "drifted", "response_condition": "errors only"},
+	}
+	preserveDefaultLogCondition(ll, d.Get("gcslogging").(*schema.Set), "not healthcheck")
+
+	// The inherited condition is cleared, while one set on the endpoint and
+	// one changed outside of Terraform are kept
+	if _, ok := ll[0]["response_condition"]; ok {
+		t.Fatalf("Expected the inherited response_condition to be cleared, got: %#v", ll[0])
+	}
+	if ll[1]["response_condition"] != "not healthcheck" {
+		t.Fatalf("Expected the explicit response_condition to be kept, got: %#v", ll[1])
+	}
+	if ll[2]["response_condition"] != "errors only" {
+		t.Fatalf("Expected the drifted response_condition to be kept, got: %#v", ll[2])
+	}
+}
+
+func TestFastlyServiceV1_ValidateLoggingPeriod(t *testing.T) {
+	cases := []struct {
+		value    int
+		errCount int
+	}{
+		{value: 1, errCount: 0},
+		{value: 3600, errCount: 0},
+		{value: 0, errCount: 1},
+		{value: -1, errCount: 1},
+	}
+
+	for _, c := range cases {
+		_, errs := validateLoggingPeriod(c.value, "period")
+		if len(errs) != c.errCount {
+			t.Fatalf("Expected period %d to trigger %d validation errors, got %d", c.value, c.errCount, len(errs))
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateLoggingFormat(t *testing.T) {
+	cases := []struct {
+		value     string
+		warnCount int
+	}{
+		{value: "%h %l %u %t %r %>s", warnCount: 0},
+		{value: "%h %{%Y-%m-%d}t \"%r\" %>s %b %{Referer}i 100%%", warnCount: 0},
+		{value: "%{req.http.host}V %{now}V %{client.ip}V", warnCount: 0},
+		{value: "%!200,304{User-Agent}i", warnCount: 0},
+		{value: "%h %Z", warnCount: 1},
+		{value: "%{Referer i", warnCount: 1},
+		{value: "%h %", warnCount: 1},
+		{value: "%i", warnCount: 1},
+		{value: "%{host}V", warnCount: 1},
+		{value: "%Z %{host}V", warnCount: 2},
+	}
+
+	for _, c := range cases {
+		ws, errs := validateLoggingFormat(c.value, "format")
+		if len(errs) != 0 {
+			t.Fatalf("Expected format %q to only trigger warnings, got errors: %s", c.value, errs)
+		}
+		if len(ws) != c.warnCount {
+			t.Fatalf("Expected format %q to trigger %d validation warnings, got %d: %s", c.value, c.warnCount, len(ws), ws)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateLoggingGzipLevel(t *testing.T) {
+	cases := []struct {
+		value    int
+		errCount int
+	}{
+		{value: 0, errCount: 0},
+		{value: 9, errCount: 0},
+		{value: 10, errCount: 1},
+		{value: 99, errCount: 1},
+		{value: -1, errCount: 1},
+	}
+
+	for _, c := range cases {
+		_, errs := validateLoggingGzipLevel(c.value, "gzip_level")
+		if len(errs) != c.errCount {
+			t.Fatalf("Expected gzip_level %d to trigger %d validation errors, got %d", c.value, c.errCount, len(errs))
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_gcslogging_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.GCS{
+		Version:         "1",
+		Name:            "gcs-endpoint",
+		User:            "logs@example.iam.gserviceaccount.com",
+		Bucket:          "fastly-logs",
+		SecretKey:       "secret",
+		Path:            "",
+		Period:          uint(3600),
+		GzipLevel:       uint8(0),
+		Format:          "%h %l %u %t %r %>s",
+		TimestampFormat: "%Y-%m-%dT%H:%M:%S.000",
+	}
+
+	log2 := gofastly.GCS{
+		Version:           "1",
+		Name:              "gcs-errors",
+		User:              "logs@example.iam.gserviceaccount.com",
+		Bucket:            "fastly-errors",
+		SecretKey:         "secret",
+		Path:              "/5xx/",
+		Period:            uint(60),
+		GzipLevel:         uint8(9),
+		Format:            "%h %l %u %t %r %>s %b",
+		TimestampFormat:   "%Y-%m-%dT%H:%M:%S.000",
+		ResponseCondition: "server errors",
+	}
+
+	// With default_log_condition set, the endpoint without a response_condition
+	// of its own is recreated with the default
+	log1Default := log1
+	log1Default.ResponseCondition = "server errors"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1GCSLoggingConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GCSLoggingAttributes(&service, []*gofastly.GCS{&log1}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gcslogging.#", "1"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1GCSLoggingConfig_update(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GCSLoggingAttributes(&service, []*gofastly.GCS{&log1, &log2}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gcslogging.#", "2"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1GCSLoggingConfig_defaultLogCondition(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GCSLoggingAttributes(&service, []*gofastly.GCS{&log1Default, &log2}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "default_log_condition", "server errors"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gcslogging.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccFastlyServiceV1_gcslogging_drift checks that changes made to a
+// logging endpoint outside of Terraform, such as in the Fastly UI, show up in
+// the plan after a refresh and are reverted by the next apply.
+func TestAccFastlyServiceV1_gcslogging_drift(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.GCS{
+		Version:         "1",
+		Name:            "gcs-endpoint",
+		User:            "logs@example.iam.gserviceaccount.com",
+		Bucket:          "fastly-logs",
+		SecretKey:       "secret",
+		Path:            "",
+		Period:          uint(3600),
+		GzipLevel:       uint8(0),
+		Format:          "%h %l %u %t %r %>s",
+		TimestampFormat: "%Y-%m-%dT%H:%M:%S.000",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			// The endpoint is changed once it has been applied, so the plan
+			// after the follow-up refresh must not be empty
+			resource.TestStep{
+				Config: testAccServiceV1GCSLoggingConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GCSLoggingAttributes(&service, []*gofastly.GCS{&log1}),
+					testAccUpdateFastlyServiceV1GCSLogging(&service, &gofastly.UpdateGCSInput{
+						Name:            "gcs-endpoint",
+						Path:            "/edited/",
+						Period:          uint(600),
+						GzipLevel:       uint8(6),
+						TimestampFormat: "%s",
+					}),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1GCSLoggingConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GCSLoggingAttributes(&service, []*gofastly.GCS{&log1}),
+				),
+			},
+		},
+	})
+}
+
+// testAccUpdateFastlyServiceV1GCSLogging changes a GCS logging endpoint
+// outside of Terraform: the active version is cloned, the endpoint updated
+// with input on the clone, and the clone activated.
+func testAccUpdateFastlyServiceV1GCSLogging(service *gofastly.ServiceDetail, input *gofastly.UpdateGCSInput) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		clone, err := conn.CloneVersion(&gofastly.CloneVersionInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error cloning version (%s) of (%s): %s", service.ActiveVersion.Number, service.Name, err)
+		}
+
+		opts := *input
+		opts.Service = service.ID
+		opts.Version = clone.Number
+		if _, err := conn.UpdateGCS(&opts); err != nil {
+			return fmt.Errorf("[ERR] Error updating GCS (%s) for (%s), version (%s): %s", opts.Name, service.Name, clone.Number, err)
+		}
+
+		if _, err := conn.ActivateVersion(&gofastly.ActivateVersionInput{
+			Service: service.ID,
+			Version: clone.Number,
+		}); err != nil {
+			return fmt.Errorf("[ERR] Error activating version (%s) of (%s): %s", clone.Number, service.Name, err)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1GCSLoggingAttributes checks that the active
+// version has exactly the expected GCS logging endpoints.
+func testAccCheckFastlyServiceV1GCSLoggingAttributes(service *gofastly.ServiceDetail, gcsList []*gofastly.GCS) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		remote, err := conn.ListGCSs(&gofastly.ListGCSsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up GCS for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(remote) != len(gcsList) {
+			return fmt.Errorf("GCS count mismatch, expected (%d), got (%d)", len(gcsList), len(remote))
+		}
+
+		var found int
+		for _, g := range gcsList {
+			for _, rg := range remote {
+				if g.Name == rg.Name {
+					// we don't know these things ahead of time, so populate them now
+					g.ServiceID = service.ID
+					g.Version = service.ActiveVersion.Number
+					if !reflect.DeepEqual(g, rg) {
+						return fmt.Errorf("Bad match GCS logging match, expected (%#v), got (%#v)", g, rg)
+					}
+					found++
+				}
+			}
+		}
+
+		if found != len(gcsList) {
+			return fmt.Errorf("Error matching GCS Logging rules")
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1GCSLoggingConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  gcslogging {
+    name        = "gcs-endpoint"
+    email       = "logs@example.iam.gserviceaccount.com"
+    bucket_name = "fastly-logs"
+    secret_key  = "secret"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1GCSLoggingConfig_update(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "server errors"
+    statement = "resp.status >= 500"
+    type      = "RESPONSE"
+  }
+
+  gcslogging {
+    name        = "gcs-endpoint"
+    email       = "logs@example.iam.gserviceaccount.com"
+    bucket_name = "fastly-logs"
+    secret_key  = "secret"
+  }
+
+  gcslogging {
+    name               = "gcs-errors"
+    email              = "logs@example.iam.gserviceaccount.com"
+    bucket_name        = "fastly-errors"
+    secret_key         = "secret"
+    path               = "/5xx/"
+    period             = 60
+    gzip_level         = 9
+    format             = "%%h %%l %%u %%t %%r %%>s %%b"
+    response_condition = "server errors"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1GCSLoggingConfig_defaultLogCondition(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "server errors"
+    statement = "resp.status >= 500"
+    type      = "RESPONSE"
+  }
+
+  default_log_condition = "server errors"
+
+  gcslogging {
+    name        = "gcs-endpoint"
+    email       = "logs@example.iam.gserviceaccount.com"
+    bucket_name = "fastly-logs"
+    secret_key  = "secret"
+  }
+
+  gcslogging {
+    name               = "gcs-errors"
+    email              = "logs@example.iam.gserviceaccount.com"
+    bucket_name        = "fastly-errors"
+    secret_key         = "secret"
+    path               = "/5xx/"
+    period             = 60
+    gzip_level         = 9
+    format             = "%%h %%l %%u %%t %%r %%>s %%b"
+    response_condition = "server errors"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
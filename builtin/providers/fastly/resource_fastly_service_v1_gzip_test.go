@@ -56,6 +56,22 @@ func TestFastlyServiceV1_FlattenGzips(t *testing.T) {
 				},
 			},
 		},
+		{
+			remote: []*gofastly.Gzip{
+				&gofastly.Gzip{
+					Name:         "reorderedgzip",
+					Extensions:   " js  css ",
+					ContentTypes: "text/xml text/html",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":          "reorderedgzip",
+					"extensions":    schema.NewSet(schema.HashString, []interface{}{"css", "js"}),
+					"content_types": schema.NewSet(schema.HashString, []interface{}{"text/html", "text/xml"}),
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -101,6 +117,97 @@ func TestFastlyServiceV1_FlattenGzips(t *testing.T) {
 	}
 }
 
+func TestFastlyServiceV1_ExpandGzipContentTypes(t *testing.T) {
+	cases := []struct {
+		in        []interface{}
+		expected  []interface{}
+		expectErr bool
+	}{
+		{
+			in:       []interface{}{"text/html", "application/json"},
+			expected: []interface{}{"text/html", "application/json"},
+		},
+		{
+			in: []interface{}{"text/*", "text/html", "image/svg+xml"},
+			expected: []interface{}{
+				"text/css", "text/csv", "text/html", "text/javascript",
+				"text/markdown", "text/plain", "text/xml", "image/svg+xml",
+			},
+		},
+		{
+			in:        []interface{}{"video/*"},
+			expectErr: true,
+		},
+		{
+			in:        []interface{}{"text/ht*"},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		out, err := expandGzipContentTypes(schema.NewSet(schema.HashString, c.in))
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+
+		expected := schema.NewSet(schema.HashString, c.expected)
+		if !out.Equal(expected) {
+			t.Fatalf("%d: Error matching:\nexpected: %#v\ngot: %#v", i, expected.List(), out.List())
+		}
+	}
+}
+
+func TestFastlyServiceV1_CollapseGzipContentTypes(t *testing.T) {
+	textTypes := make([]interface{}, 0, len(gzipContentTypeWildcards["text/*"]))
+	for _, ct := range gzipContentTypeWildcards["text/*"] {
+		textTypes = append(textTypes, ct)
+	}
+
+	d := resourceServiceV1().TestResourceData()
+	err := d.Set("gzip", []interface{}{
+		map[string]interface{}{
+			"name":          "wildcard",
+			"content_types": schema.NewSet(schema.HashString, []interface{}{"text/*", "text/html", "image/svg+xml"}),
+		},
+		map[string]interface{}{
+			"name":          "drifted",
+			"content_types": schema.NewSet(schema.HashString, []interface{}{"text/*"}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("error setting gzips: %s", err)
+	}
+
+	gl := []map[string]interface{}{
+		map[string]interface{}{
+			"name":          "wildcard",
+			"content_types": schema.NewSet(schema.HashString, append([]interface{}{"image/svg+xml"}, textTypes...)),
+		},
+		map[string]interface{}{
+			"name":          "drifted",
+			"content_types": schema.NewSet(schema.HashString, []interface{}{"text/html", "text/css"}),
+		},
+	}
+	collapseGzipContentTypes(gl, d.Get("gzip").(*schema.Set))
+
+	expected := []*schema.Set{
+		schema.NewSet(schema.HashString, []interface{}{"text/*", "text/html", "image/svg+xml"}),
+		// Not every text/* type is present, so the remote types are kept
+		schema.NewSet(schema.HashString, []interface{}{"text/html", "text/css"}),
+	}
+	for i, g := range gl {
+		if out := g["content_types"].(*schema.Set); !out.Equal(expected[i]) {
+			t.Fatalf("%d: Error matching:\nexpected: %#v\ngot: %#v", i, expected[i].List(), out.List())
+		}
+	}
+}
+
 func TestAccFastlyServiceV1_gzips_basic(t *testing.T) {
 	var service gofastly.ServiceDetail
 	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
@@ -121,13 +228,13 @@ func TestAccFastlyServiceV1_gzips_basic(t *testing.T) {
 					resource.TestCheckResourceAttr(
 						"fastly_service_v1.foo", "gzip.#", "2"),
 					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "gzip.3704620722.extensions.#", "2"),
+						"fastly_service_v1.foo", "gzip.2170976774.extensions.#", "2"),
 					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "gzip.3704620722.content_types.#", "0"),
+						"fastly_service_v1.foo", "gzip.2170976774.content_types.#", "0"),
 					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "gzip.3820313126.content_types.#", "2"),
+						"fastly_service_v1.foo", "gzip.1620092374.content_types.#", "2"),
 					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "gzip.3820313126.extensions.#", "0"),
+						"fastly_service_v1.foo", "gzip.1620092374.extensions.#", "0"),
 				),
 			},
 
@@ -141,15 +248,84 @@ func TestAccFastlyServiceV1_gzips_basic(t *testing.T) {
 					resource.TestCheckResourceAttr(
 						"fastly_service_v1.foo", "gzip.#", "1"),
 					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "gzip.3694165387.extensions.#", "3"),
+						"fastly_service_v1.foo", "gzip.3491643365.extensions.#", "3"),
 					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "gzip.3694165387.content_types.#", "5"),
+						"fastly_service_v1.foo", "gzip.3491643365.content_types.#", "5"),
+				),
+			},
+
+			// Reordering the members of content_types and extensions must not
+			// produce a diff
+			resource.TestStep{
+				Config: testAccServiceV1GzipsConfig_reordered(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GzipsAttributes(&service, name, 1),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gzip.#", "1"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gzip.3491643365.extensions.#", "3"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gzip.3491643365.content_types.#", "5"),
 				),
 			},
 		},
 	})
 }
 
+func TestAccFastlyServiceV1_gzips_contentTypeWildcard(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	expected := append([]string{"image/svg+xml"}, gzipContentTypeWildcards["text/*"]...)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1GzipsConfig_wildcard(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1GzipContentTypes(&service, "gzip text", expected),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "gzip.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1GzipContentTypes checks the content types Fastly
+// has for the named gzip rule on the active version.
+func testAccCheckFastlyServiceV1GzipContentTypes(service *gofastly.ServiceDetail, gzip string, expected []string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		g, err := conn.GetGzip(&gofastly.GetGzipInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+			Name:    gzip,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Gzip (%s) for (%s), version (%s): %s", gzip, service.Name, service.ActiveVersion.Number, err)
+		}
+
+		want := make([]interface{}, 0, len(expected))
+		for _, ct := range expected {
+			want = append(want, ct)
+		}
+
+		got := schema.NewSet(schema.HashString, splitGzipList(g.ContentTypes))
+		if !got.Equal(schema.NewSet(schema.HashString, want)) {
+			return fmt.Errorf("Gzip (%s) content types mismatch, expected (%v), got (%s)", gzip, expected, g.ContentTypes)
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckFastlyServiceV1GzipsAttributes(service *gofastly.ServiceDetail, name string, gzipCount int) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 
@@ -236,3 +412,59 @@ resource "fastly_service_v1" "foo" {
   force_destroy = true
 }`, name, domain)
 }
+
+func testAccServiceV1GzipsConfig_reordered(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  gzip {
+    name       = "all"
+    extensions = ["html", "js", "css"]
+
+    content_types = [
+      "text/javascript",
+      "application/javascript",
+      "text/css",
+      "application/x-javascript",
+      "text/html",
+    ]
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1GzipsConfig_wildcard(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  gzip {
+    name          = "gzip text"
+    content_types = ["text/*", "image/svg+xml"]
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
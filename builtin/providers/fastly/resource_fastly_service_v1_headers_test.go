@@ -75,6 +75,35 @@ func TestFastlyServiceV1_BuildHeaders(t *testing.T) {
 	}
 }
 
+func TestFastlyServiceV1_FlattenHeaders_forceTLS(t *testing.T) {
+	remote := []*gofastly.Header{
+		&gofastly.Header{
+			Name:        "someheadder",
+			Action:      gofastly.HeaderActionDelete,
+			Type:        gofastly.HeaderTypeCache,
+			Destination: "http.aws-id",
+			Priority:    uint(100),
+		},
+		&gofastly.Header{
+			Name:        fastlyForceTLSHeaderName,
+			Action:      gofastly.HeaderActionSet,
+			Type:        gofastly.HeaderTypeResponse,
+			Destination: "http.Strict-Transport-Security",
+			Source:      `"max-age=31536000"`,
+			Priority:    uint(100),
+		},
+	}
+
+	out := flattenHeaders(remote)
+	if len(out) != 1 {
+		t.Fatalf("Expected the force_tls header to be excluded, got: %#v", out)
+	}
+
+	if out[0]["name"] != "someheadder" {
+		t.Fatalf("Expected header someheadder, got: %#v", out[0])
+	}
+}
+
 func TestAccFastlyServiceV1_headers_basic(t *testing.T) {
 	var service gofastly.ServiceDetail
 	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
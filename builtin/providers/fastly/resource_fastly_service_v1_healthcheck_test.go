@@ -0,0 +1,238 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenHealthchecks(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.HealthCheck
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.HealthCheck{
+				&gofastly.HealthCheck{
+					Name:             "example-healthcheck",
+					Host:             "example.com",
+					Path:             "/status",
+					CheckInterval:    4000,
+					ExpectedResponse: 204,
+					HTTPVersion:      "1.0",
+					Initial:          1,
+					Method:           "GET",
+					Threshold:        2,
+					Timeout:          750,
+					Window:           4,
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":              "example-healthcheck",
+					"host":              "example.com",
+					"path":              "/status",
+					"check_interval":    4000,
+					"expected_response": 204,
+					"http_version":      "1.0",
+					"initial":           1,
+					"method":            "GET",
+					"threshold":         2,
+					"timeout":           750,
+					"window":            4,
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenHealthchecks(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidateBackendHealthchecks(t *testing.T) {
+	healthchecks := []interface{}{
+		map[string]interface{}{
+			"name": "example-healthcheck",
+			"host": "example.com",
+			"path": "/status",
+		},
+	}
+
+	cases := []struct {
+		healthcheck string
+		expectErr   bool
+	}{
+		{"", false},
+		{"example-healthcheck", false},
+		{"missing-healthcheck", true},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("healthcheck", healthchecks); err != nil {
+			t.Fatalf("%d: error setting healthchecks: %s", i, err)
+		}
+		backends := []interface{}{
+			map[string]interface{}{
+				"name":        "amazon docs",
+				"address":     "aws.amazon.com",
+				"healthcheck": c.healthcheck,
+			},
+		}
+		if err := d.Set("backend", backends); err != nil {
+			t.Fatalf("%d: error setting backends: %s", i, err)
+		}
+
+		err := validateBackendHealthchecks(d)
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_healthcheck_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1HealthcheckConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1HealthcheckPath(&service, "example-healthcheck", "/status"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "healthcheck.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_healthcheck_drift(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	// changeHealthcheck changes the path of the healthcheck outside of
+	// Terraform, by activating a new version of the service
+	changeHealthcheck := func(*terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		version, err := conn.CloneVersion(&gofastly.CloneVersionInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.UpdateHealthCheck(&gofastly.UpdateHealthCheckInput{
+			Service: service.ID,
+			Version: version.Number,
+			Name:    "example-healthcheck",
+			Path:    "/changed",
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.ActivateVersion(&gofastly.ActivateVersionInput{
+			Service: service.ID,
+			Version: version.Number,
+		})
+		return err
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			// The change shows up as a diff once refreshed
+			resource.TestStep{
+				Config: testAccServiceV1HealthcheckConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					changeHealthcheck,
+				),
+				ExpectNonEmptyPlan: true,
+			},
+
+			// Applying again restores the configured path
+			resource.TestStep{
+				Config: testAccServiceV1HealthcheckConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1HealthcheckPath(&service, "example-healthcheck", "/status"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1HealthcheckPath checks the path of the named
+// Healthcheck on the active version.
+func testAccCheckFastlyServiceV1HealthcheckPath(service *gofastly.ServiceDetail, name, path string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		h, err := conn.GetHealthCheck(&gofastly.GetHealthCheckInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+			Name:    name,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Healthcheck (%s) for (%s), version (%s): %s", name, service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if h.Path != path {
+			return fmt.Errorf("Bad Healthcheck path, expected (%s), got (%s)", path, h.Path)
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1HealthcheckConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address     = "aws.amazon.com"
+    name        = "amazon docs"
+    healthcheck = "example-healthcheck"
+  }
+
+  healthcheck {
+    name              = "example-healthcheck"
+    host              = "example.com"
+    path              = "/status"
+    check_interval    = 4000
+    expected_response = 200
+    method            = "GET"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
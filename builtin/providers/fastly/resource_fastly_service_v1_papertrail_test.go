@@ -0,0 +1,265 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenPapertrails(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.Papertrail
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.Papertrail{
+				&gofastly.Papertrail{
+					Name:              "papertrailtesting",
+					Address:           "logs.papertrailapp.com",
+					Port:              3600,
+					Format:            "%h %l %u %t %r %>s",
+					ResponseCondition: "test_response_condition",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":               "papertrailtesting",
+					"address":            "logs.papertrailapp.com",
+					"port":               3600,
+					"format":             "%h %l %u %t %r %>s",
+					"response_condition": "test_response_condition",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenPapertrails(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_ValidatePapertrailConditions(t *testing.T) {
+	endpoint := func(condition string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":               "papertrailtesting",
+			"address":            "logs.papertrailapp.com",
+			"port":               3600,
+			"format":             "%h %l %u %t %r %>s",
+			"response_condition": condition,
+		}
+	}
+
+	conditions := []interface{}{
+		map[string]interface{}{
+			"name":      "server errors",
+			"statement": "resp.status >= 500",
+			"type":      "RESPONSE",
+			"priority":  10,
+		},
+		map[string]interface{}{
+			"name":      "api requests",
+			"statement": "req.url ~ \"^/api/\"",
+			"type":      "REQUEST",
+			"priority":  10,
+		},
+	}
+
+	cases := []struct {
+		condition string
+		expectErr bool
+	}{
+		{condition: "", expectErr: false},
+		{condition: "server errors", expectErr: false},
+		{condition: "api requests", expectErr: true},
+		{condition: "missing", expectErr: true},
+	}
+
+	for i, c := range cases {
+		d := resourceServiceV1().TestResourceData()
+		if err := d.Set("condition", conditions); err != nil {
+			t.Fatalf("%d: error setting conditions: %s", i, err)
+		}
+		if err := d.Set("papertrail", []interface{}{endpoint(c.condition)}); err != nil {
+			t.Fatalf("%d: error setting papertrail: %s", i, err)
+		}
+
+		err := validateLoggingConditions(d, "papertrail", "Papertrail")
+		if c.expectErr && err == nil {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_papertrail_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.Papertrail{
+		Version: "1",
+		Name:    "papertrailtesting",
+		Address: "test1.papertrailapp.com",
+		Port:    uint(3600),
+		Format:  "%h %l %u %t %r %>s",
+	}
+
+	log2 := gofastly.Papertrail{
+		Version:           "1",
+		Name:              "papertrailtesting2",
+		Address:           "test2.papertrailapp.com",
+		Port:              uint(8080),
+		Format:            "%h %l %u %t %r %>s %b",
+		ResponseCondition: "server errors",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1PapertrailConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1PapertrailAttributes(&service, []*gofastly.Papertrail{&log1}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "papertrail.#", "1"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1PapertrailConfig_update(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1PapertrailAttributes(&service, []*gofastly.Papertrail{&log1, &log2}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "papertrail.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1PapertrailAttributes checks that the active
+// version has exactly the expected Papertrail logging endpoints.
+func testAccCheckFastlyServiceV1PapertrailAttributes(service *gofastly.ServiceDetail, papertrails []*gofastly.Papertrail) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		remote, err := conn.ListPapertrails(&gofastly.ListPapertrailsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Papertrail for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(remote) != len(papertrails) {
+			return fmt.Errorf("Papertrail count mismatch, expected (%d), got (%d)", len(papertrails), len(remote))
+		}
+
+		var found int
+		for _, p := range papertrails {
+			for _, rp := range remote {
+				if p.Name == rp.Name {
+					// we don't know these things ahead of time, so populate them now
+					p.ServiceID = service.ID
+					p.Version = service.ActiveVersion.Number
+					// We don't track these, so clear them out because we also won't know
+					// these ahead of time
+					rp.CreatedAt = nil
+					rp.UpdatedAt = nil
+					if !reflect.DeepEqual(p, rp) {
+						return fmt.Errorf("Bad match Papertrail match, expected (%#v), got (%#v)", p, rp)
+					}
+					found++
+				}
+			}
+		}
+
+		if found != len(papertrails) {
+			return fmt.Errorf("Error matching Papertrail rules")
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1PapertrailConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  papertrail {
+    name    = "papertrailtesting"
+    address = "test1.papertrailapp.com"
+    port    = 3600
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1PapertrailConfig_update(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "server errors"
+    statement = "resp.status >= 500"
+    type      = "RESPONSE"
+  }
+
+  papertrail {
+    name    = "papertrailtesting"
+    address = "test1.papertrailapp.com"
+    port    = 3600
+  }
+
+  papertrail {
+    name               = "papertrailtesting2"
+    address            = "test2.papertrailapp.com"
+    port               = 8080
+    format             = "%%h %%l %%u %%t %%r %%>s %%b"
+    response_condition = "server errors"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
@@ -0,0 +1,459 @@
+package fastly
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenS3s(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.S3
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.S3{
+				&gofastly.S3{
+					Name:              "s3-endpoint",
+					BucketName:        "fastly-logs",
+					AccessKey:         "access",
+					SecretKey:         "secret",
+					Path:              "logs",
+					Period:            3600,
+					GzipLevel:         9,
+					Format:            "%h %l %u %t %r %>s",
+					TimestampFormat:   "%Y-%m-%dT%H:%M:%S.000",
+					ResponseCondition: "test_response_condition",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":               "s3-endpoint",
+					"bucket_name":        "fastly-logs",
+					"s3_access_key":      "access",
+					"s3_secret_key":      "secret",
+					"path":               "/logs/",
+					"period":             3600,
+					"gzip_level":         9,
+					"format":             "%h %l %u %t %r %>s",
+					"timestamp_format":   "%Y-%m-%dT%H:%M:%S.000",
+					"response_condition": "test_response_condition",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenS3s(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_NormalizeS3LoggingPath(t *testing.T) {
+	cases := []struct {
+		path     interface{}
+		expected string
+	}{
+		{path: nil, expected: ""},
+		{path: "", expected: ""},
+		{path: "/", expected: "/"},
+		{path: "/logs", expected: "/logs/"},
+		{path: "logs/", expected: "/logs/"},
+		{path: "/logs/", expected: "/logs/"},
+		{path: "logs/cdn", expected: "/logs/cdn/"},
+	}
+
+	for _, c := range cases {
+		if out := normalizeS3LoggingPath(c.path); out != c.expected {
+			t.Fatalf("%#v: expected %q, got %q", c.path, c.expected, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_HashS3Logging(t *testing.T) {
+	endpoint := func(path string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":               "s3-endpoint",
+			"bucket_name":        "fastly-logs",
+			"s3_access_key":      "access",
+			"s3_secret_key":      "secret",
+			"path":               path,
+			"period":             3600,
+			"gzip_level":         0,
+			"format":             "%h %l %u %t %r %>s",
+			"timestamp_format":   "%Y-%m-%dT%H:%M:%S.000",
+			"response_condition": "",
+		}
+	}
+
+	// Paths differing only in their slashes are the same endpoint
+	if hashS3Logging(endpoint("logs")) != hashS3Logging(endpoint("/logs/")) {
+		t.Fatalf("Expected paths differing only in slashes to hash the same")
+	}
+	if hashS3Logging(endpoint("logs")) == hashS3Logging(endpoint("other")) {
+		t.Fatalf("Expected endpoints with different paths to hash differently")
+	}
+}
+
+func TestFastlyServiceV1_ApplyLoggingEndpoints(t *testing.T) {
+	backoff := loggingEndpointRateLimitBackoff
+	loggingEndpointRateLimitBackoff = time.Millisecond
+	defer func() { loggingEndpointRateLimitBackoff = backoff }()
+
+	var endpoints []interface{}
+	for i := 0; i < 20; i++ {
+		endpoints = append(endpoints, map[string]interface{}{"name": fmt.Sprintf("log%02d", i)})
+	}
+
+	// Calls are bounded, and the rate limited ones are retried
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	attempts := make(map[string]int)
+	err := applyLoggingEndpoints("S3 logging", "creating", endpoints, func(ef map[string]interface{}) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		name := ef["name"].(string)
+		attempts[name]++
+		attempt := attempts[name]
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if attempt < 3 {
+			return &gofastly.HTTPError{StatusCode: 429}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if maxInFlight > loggingEndpointConcurrency {
+		t.Fatalf("Expected at most %d calls in flight, got %d", loggingEndpointConcurrency, maxInFlight)
+	}
+	for _, eRaw := range endpoints {
+		name := eRaw.(map[string]interface{})["name"].(string)
+		if attempts[name] != 3 {
+			t.Fatalf("Expected 3 attempts for %s, got %d", name, attempts[name])
+		}
+	}
+
+	// Every endpoint is tried, and the failures are reported in order along
+	// with the endpoints which were applied
+	var calls int
+	err = applyLoggingEndpoints("S3 logging", "creating", endpoints[:4], func(ef map[string]interface{}) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		switch ef["name"].(string) {
+		case "log01":
+			return errors.New("bad bucket")
+		case "log03":
+			return &gofastly.HTTPError{StatusCode: 400}
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if calls != 4 {
+		t.Fatalf("Expected all 4 endpoints to be tried, got %d", calls)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "Error creating 2 of 4 S3 logging endpoints") || !strings.Contains(msg, "Endpoints applied: log00, log02.") {
+		t.Fatalf("Unexpected error: %s", msg)
+	}
+	if i, j := strings.Index(msg, `"log01": bad bucket`), strings.Index(msg, `"log03": 400`); i < 0 || j < 0 || i > j {
+		t.Fatalf("Expected the failures in endpoint order, got: %s", msg)
+	}
+
+	// A call still rate limited after the retries fails
+	attempts = make(map[string]int)
+	err = applyLoggingEndpoints("S3 logging", "deleting", endpoints[:1], func(ef map[string]interface{}) error {
+		attempts[ef["name"].(string)]++
+		return &gofastly.HTTPError{StatusCode: 429}
+	})
+	if err == nil {
+		t.Fatalf("Expected an error once the retries are used up")
+	}
+	if attempts["log00"] != loggingEndpointRateLimitRetries+1 {
+		t.Fatalf("Expected %d attempts, got %d", loggingEndpointRateLimitRetries+1, attempts["log00"])
+	}
+}
+
+func TestAccFastlyServiceV1_s3logging_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.S3{
+		Version:         "1",
+		Name:            "somebucketlog",
+		BucketName:      "fastlytestlogging",
+		AccessKey:       "somekey",
+		SecretKey:       "somesecret",
+		Period:          uint(3600),
+		GzipLevel:       uint(0),
+		Format:          "%h %l %u %t %r %>s",
+		TimestampFormat: "%Y-%m-%dT%H:%M:%S.000",
+	}
+
+	log2 := gofastly.S3{
+		Version:           "1",
+		Name:              "someotherbucketlog",
+		BucketName:        "fastlytestlogging2",
+		AccessKey:         "someotherkey",
+		SecretKey:         "someothersecret",
+		Period:            uint(3600),
+		GzipLevel:         uint(3),
+		Format:            "%h %l %u %t %r %>s %b",
+		TimestampFormat:   "%Y-%m-%dT%H:%M:%S.000",
+		ResponseCondition: "server errors",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1S3LoggingConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1S3LoggingAttributes(&service, []*gofastly.S3{&log1}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "s3logging.#", "1"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1S3LoggingConfig_update(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1S3LoggingAttributes(&service, []*gofastly.S3{&log1, &log2}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "s3logging.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_s3logging_drift(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.S3{
+		Version:         "1",
+		Name:            "somebucketlog",
+		BucketName:      "fastlytestlogging",
+		AccessKey:       "somekey",
+		SecretKey:       "somesecret",
+		Period:          uint(3600),
+		GzipLevel:       uint(0),
+		Format:          "%h %l %u %t %r %>s",
+		TimestampFormat: "%Y-%m-%dT%H:%M:%S.000",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			// The endpoint is changed once it has been applied, so the plan
+			// after the follow-up refresh must not be empty
+			resource.TestStep{
+				Config: testAccServiceV1S3LoggingConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1S3LoggingAttributes(&service, []*gofastly.S3{&log1}),
+					testAccUpdateFastlyServiceV1S3Logging(&service, &gofastly.UpdateS3Input{
+						Name:            "somebucketlog",
+						Path:            "/edited/",
+						Period:          uint(600),
+						GzipLevel:       uint(6),
+						Format:          "%h %l %u %t %r %>s %b",
+						TimestampFormat: "%s",
+					}),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1S3LoggingConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1S3LoggingAttributes(&service, []*gofastly.S3{&log1}),
+				),
+			},
+		},
+	})
+}
+
+// testAccUpdateFastlyServiceV1S3Logging changes an S3 logging endpoint
+// outside of Terraform: the active version is cloned, the endpoint updated
+// with input on the clone, and the clone activated.
+func testAccUpdateFastlyServiceV1S3Logging(service *gofastly.ServiceDetail, input *gofastly.UpdateS3Input) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		clone, err := conn.CloneVersion(&gofastly.CloneVersionInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error cloning version (%s) of (%s): %s", service.ActiveVersion.Number, service.Name, err)
+		}
+
+		opts := *input
+		opts.Service = service.ID
+		opts.Version = clone.Number
+		if _, err := conn.UpdateS3(&opts); err != nil {
+			return fmt.Errorf("[ERR] Error updating S3 (%s) for (%s), version (%s): %s", opts.Name, service.Name, clone.Number, err)
+		}
+
+		if _, err := conn.ActivateVersion(&gofastly.ActivateVersionInput{
+			Service: service.ID,
+			Version: clone.Number,
+		}); err != nil {
+			return fmt.Errorf("[ERR] Error activating version (%s) of (%s): %s", clone.Number, service.Name, err)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1S3LoggingAttributes checks that the active
+// version has exactly the expected S3 logging endpoints.
+func testAccCheckFastlyServiceV1S3LoggingAttributes(service *gofastly.ServiceDetail, s3s []*gofastly.S3) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		remote, err := conn.ListS3s(&gofastly.ListS3sInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up S3 Logging for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(remote) != len(s3s) {
+			return fmt.Errorf("S3 Logging count mismatch, expected (%d), got (%d)", len(s3s), len(remote))
+		}
+
+		var found int
+		for _, s3 := range s3s {
+			for _, rs3 := range remote {
+				if s3.Name == rs3.Name {
+					// we don't know these things ahead of time, so populate them now
+					s3.ServiceID = service.ID
+					s3.Version = service.ActiveVersion.Number
+					// We don't track these, so clear them out because we also won't know
+					// these ahead of time
+					rs3.CreatedAt = nil
+					rs3.UpdatedAt = nil
+					rs3.DeletedAt = nil
+					if !reflect.DeepEqual(s3, rs3) {
+						return fmt.Errorf("Bad match S3 logging match, expected (%#v), got (%#v)", s3, rs3)
+					}
+					found++
+				}
+			}
+		}
+
+		if found != len(s3s) {
+			return fmt.Errorf("Error matching S3 Logging rules")
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1S3LoggingConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  s3logging {
+    name          = "somebucketlog"
+    bucket_name   = "fastlytestlogging"
+    s3_access_key = "somekey"
+    s3_secret_key = "somesecret"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1S3LoggingConfig_update(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "server errors"
+    statement = "resp.status >= 500"
+    type      = "RESPONSE"
+  }
+
+  s3logging {
+    name          = "somebucketlog"
+    bucket_name   = "fastlytestlogging"
+    s3_access_key = "somekey"
+    s3_secret_key = "somesecret"
+  }
+
+  s3logging {
+    name               = "someotherbucketlog"
+    bucket_name        = "fastlytestlogging2"
+    s3_access_key      = "someotherkey"
+    s3_secret_key      = "someothersecret"
+    gzip_level         = 3
+    format             = "%%h %%l %%u %%t %%r %%>s %%b"
+    response_condition = "server errors"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
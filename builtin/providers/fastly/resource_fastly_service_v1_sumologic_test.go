@@ -0,0 +1,204 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+func TestFastlyServiceV1_FlattenSumologics(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.Sumologic
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.Sumologic{
+				&gofastly.Sumologic{
+					Name:              "sumologictesting",
+					URL:               "https://collectors.sumologic.com/receiver/v1/http/abc123",
+					Format:            "%h %l %u %t %r %>s",
+					ResponseCondition: "test_response_condition",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":               "sumologictesting",
+					"url":                "https://collectors.sumologic.com/receiver/v1/http/abc123",
+					"format":             "%h %l %u %t %r %>s",
+					"response_condition": "test_response_condition",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenSumologics(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestAccFastlyServiceV1_sumologic_basic(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.Sumologic{
+		Version: "1",
+		Name:    "sumologictesting",
+		URL:     "https://collectors.sumologic.com/receiver/v1/http/test1",
+		Format:  "%h %l %u %t %r %>s",
+	}
+
+	log2 := gofastly.Sumologic{
+		Version:           "1",
+		Name:              "sumologictesting2",
+		URL:               "https://collectors.sumologic.com/receiver/v1/http/test2",
+		Format:            "%h %l %u %t %r %>s %b",
+		ResponseCondition: "server errors",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1SumologicConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1SumologicAttributes(&service, []*gofastly.Sumologic{&log1}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "sumologic.#", "1"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1SumologicConfig_update(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1SumologicAttributes(&service, []*gofastly.Sumologic{&log1, &log2}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "sumologic.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1SumologicAttributes checks that the active
+// version has exactly the expected Sumo Logic logging endpoints.
+func testAccCheckFastlyServiceV1SumologicAttributes(service *gofastly.ServiceDetail, sumologics []*gofastly.Sumologic) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		remote, err := conn.ListSumologics(&gofastly.ListSumologicsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Sumo Logic for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(remote) != len(sumologics) {
+			return fmt.Errorf("Sumo Logic count mismatch, expected (%d), got (%d)", len(sumologics), len(remote))
+		}
+
+		var found int
+		for _, sl := range sumologics {
+			for _, rsl := range remote {
+				if sl.Name == rsl.Name {
+					// we don't know these things ahead of time, so populate them now
+					sl.ServiceID = service.ID
+					sl.Version = service.ActiveVersion.Number
+					// We don't track these, so clear them out because we also won't know
+					// these ahead of time
+					rsl.CreatedAt = nil
+					rsl.UpdatedAt = nil
+					rsl.DeletedAt = nil
+					if !reflect.DeepEqual(sl, rsl) {
+						return fmt.Errorf("Bad match Sumo Logic match, expected (%#v), got (%#v)", sl, rsl)
+					}
+					found++
+				}
+			}
+		}
+
+		if found != len(sumologics) {
+			return fmt.Errorf("Error matching Sumo Logic rules")
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1SumologicConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  sumologic {
+    name = "sumologictesting"
+    url  = "https://collectors.sumologic.com/receiver/v1/http/test1"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+func testAccServiceV1SumologicConfig_update(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  condition {
+    name      = "server errors"
+    statement = "resp.status >= 500"
+    type      = "RESPONSE"
+  }
+
+  sumologic {
+    name = "sumologictesting"
+    url  = "https://collectors.sumologic.com/receiver/v1/http/test1"
+  }
+
+  sumologic {
+    name               = "sumologictesting2"
+    url                = "https://collectors.sumologic.com/receiver/v1/http/test2"
+    format             = "%%h %%l %%u %%t %%r %%>s %%b"
+    response_condition = "server errors"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
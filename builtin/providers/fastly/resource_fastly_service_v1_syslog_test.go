@@ -0,0 +1,294 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	gofastly "github.com/sethvargo/go-fastly"
+)
+
+// testAccSyslogCACert is a self-signed CA certificate, used only to configure
+// a TLS Syslog endpoint. Nothing is ever verified with it.
+const testAccSyslogCACert = `-----BEGIN CERTIFICATE-----
+MIICFDCCAX2gAwIBAgIUL2u2Ya4LF9TuXgHhlwgvf0GkgL8wDQYJKoZIhvcNAQEL
+BQAwHDEaMBgGA1UEAwwRdGYtdGVzdC1zeXNsb2ctY2EwHhcNMjYxMDE2MTU1NzMx
+WhcNMzYxMDEzMTU1NzMxWjAcMRowGAYDVQQDDBF0Zi10ZXN0LXN5c2xvZy1jYTCB
+nzANBgkqhkiG9w0BAQEFAAOBjQAwgYkCgYEA2umO6/mIihLEGiBUiKbqOHMtzJuo
+XQ97hQqtceRUOfAzS8vNxKHJjzLPpTfQ1nH8xvqZgVGXy0m0L5vAXknV53gMbb/n
+a7b9ZN4oCncB3gyoI+soFgSmFXbOB6tV+xpLWNLyniexoz4ZZCdiERmcQawMaE5z
+24cXwDxMVDobyl0CAwEAAaNTMFEwHQYDVR0OBBYEFMRumpHqZ6MlO9CWj4MhxKDj
+xq8LMB8GA1UdIwQYMBaAFMRumpHqZ6MlO9CWj4MhxKDjxq8LMA8GA1UdEwEB/wQF
+MAMBAf8wDQYJKoZIhvcNAQELBQADgYEAbXvSuYfLoQwLVQBtS7gh8pFIwFTZtCwM
+zQ4tS28FlwZIFgTTdnRvUVYMAAkiMRX1qVMG3vDFmkYKcHZFVWonyZKXAAt0RrHE
+lYOo4xXp3i/WoOJt+a/7jgp39YSt0LFKjPpBKLt2+1gQdesiSwYZ8K6zXdmmxRJ5
+LN1aUT+Qx9w=
+-----END CERTIFICATE-----
+`
+
+func TestFastlyServiceV1_FlattenSyslogs(t *testing.T) {
+	cases := []struct {
+		remote []*gofastly.Syslog
+		local  []map[string]interface{}
+	}{
+		{
+			remote: []*gofastly.Syslog{
+				&gofastly.Syslog{
+					Name:              "syslogtesting",
+					Address:           "logs.example.com",
+					Port:              6514,
+					Token:             "tf-test",
+					UseTLS:            true,
+					TLSCACert:         "\r\n" + strings.Replace(testAccSyslogCACert, "\n", "\r\n", -1),
+					Format:            "%h %l %u %t %r %>s",
+					ResponseCondition: "test_response_condition",
+				},
+				&gofastly.Syslog{
+					Name:    "plainsyslog",
+					Address: "logs.example.com",
+					Port:    514,
+					Format:  "%h %l %u %t %r %>s",
+				},
+			},
+			local: []map[string]interface{}{
+				map[string]interface{}{
+					"name":               "syslogtesting",
+					"address":            "logs.example.com",
+					"port":               6514,
+					"token":              "tf-test",
+					"use_tls":            true,
+					"tls_ca_cert":        testAccSyslogCACert,
+					"format":             "%h %l %u %t %r %>s",
+					"response_condition": "test_response_condition",
+				},
+				map[string]interface{}{
+					"name":    "plainsyslog",
+					"address": "logs.example.com",
+					"port":    514,
+					"use_tls": false,
+					"format":  "%h %l %u %t %r %>s",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		out := flattenSyslogs(c.remote)
+		if !reflect.DeepEqual(out, c.local) {
+			t.Fatalf("Error matching:\nexpected: %#v\ngot: %#v", c.local, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_NormalizeSyslogCert(t *testing.T) {
+	cases := []struct {
+		cert     interface{}
+		expected string
+	}{
+		{cert: nil, expected: ""},
+		{cert: "", expected: ""},
+		{cert: " \n\t\n", expected: ""},
+		{cert: testAccSyslogCACert, expected: testAccSyslogCACert},
+		{cert: strings.TrimSpace(testAccSyslogCACert), expected: testAccSyslogCACert},
+		{cert: "\n  " + strings.Replace(testAccSyslogCACert, "\n", "  \r\n  ", -1) + "\n\n", expected: testAccSyslogCACert},
+	}
+
+	for i, c := range cases {
+		if out := normalizeSyslogCert(c.cert); out != c.expected {
+			t.Fatalf("%d: expected %q, got %q", i, c.expected, out)
+		}
+	}
+}
+
+func TestFastlyServiceV1_HashSyslog(t *testing.T) {
+	endpoint := func(cert string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":               "syslogtesting",
+			"address":            "logs.example.com",
+			"port":               6514,
+			"token":              "",
+			"use_tls":            true,
+			"tls_ca_cert":        cert,
+			"format":             "%h %l %u %t %r %>s",
+			"response_condition": "",
+		}
+	}
+
+	// Certificates differing only in whitespace are the same endpoint
+	if hashSyslog(endpoint(testAccSyslogCACert)) != hashSyslog(endpoint("  "+strings.TrimSpace(testAccSyslogCACert))) {
+		t.Fatalf("Expected certificates differing only in whitespace to hash the same")
+	}
+	if hashSyslog(endpoint(testAccSyslogCACert)) == hashSyslog(endpoint("")) {
+		t.Fatalf("Expected endpoints with different certificates to hash differently")
+	}
+}
+
+func TestAccFastlyServiceV1_syslog_tls(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	log1 := gofastly.Syslog{
+		Version: "1",
+		Name:    "syslogtesting",
+		Address: "syslog.example.com",
+		Port:    uint(514),
+		Format:  "%h %l %u %t %r %>s",
+	}
+
+	log2 := gofastly.Syslog{
+		Version:   "1",
+		Name:      "syslogtesting-tls",
+		Address:   "syslog-tls.example.com",
+		Port:      uint(6514),
+		Token:     "tf-test",
+		UseTLS:    true,
+		TLSCACert: testAccSyslogCACert,
+		Format:    "%h %l %u %t %r %>s %b",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1SyslogConfig(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1SyslogAttributes(&service, []*gofastly.Syslog{&log1}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "syslog.#", "1"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1SyslogConfig_tls(name, domainName1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1SyslogAttributes(&service, []*gofastly.Syslog{&log1, &log2}),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "name", name),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "syslog.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckFastlyServiceV1SyslogAttributes checks that the active version
+// has exactly the expected Syslog logging endpoints.
+func testAccCheckFastlyServiceV1SyslogAttributes(service *gofastly.ServiceDetail, syslogs []*gofastly.Syslog) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		remote, err := conn.ListSyslogs(&gofastly.ListSyslogsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Syslog for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		if len(remote) != len(syslogs) {
+			return fmt.Errorf("Syslog count mismatch, expected (%d), got (%d)", len(syslogs), len(remote))
+		}
+
+		var found int
+		for _, sl := range syslogs {
+			for _, rsl := range remote {
+				if sl.Name == rsl.Name {
+					// we don't know these things ahead of time, so populate them now
+					sl.ServiceID = service.ID
+					sl.Version = service.ActiveVersion.Number
+					// We don't track these, so clear them out because we also won't know
+					// these ahead of time
+					rsl.CreatedAt = nil
+					rsl.UpdatedAt = nil
+					rsl.DeletedAt = nil
+					// Certificates are compared as they are kept in state
+					rsl.TLSCACert = normalizeSyslogCert(rsl.TLSCACert)
+					if !reflect.DeepEqual(sl, rsl) {
+						return fmt.Errorf("Bad match Syslog match, expected (%#v), got (%#v)", sl, rsl)
+					}
+					found++
+				}
+			}
+		}
+
+		if found != len(syslogs) {
+			return fmt.Errorf("Error matching Syslog rules")
+		}
+
+		return nil
+	}
+}
+
+func testAccServiceV1SyslogConfig(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  syslog {
+    name    = "syslogtesting"
+    address = "syslog.example.com"
+  }
+
+  force_destroy = true
+}`, name, domain)
+}
+
+// testAccServiceV1SyslogConfig_tls indents the CA certificate, which must be
+// normalized for the plan to be empty after apply.
+func testAccServiceV1SyslogConfig_tls(name, domain string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  syslog {
+    name    = "syslogtesting"
+    address = "syslog.example.com"
+  }
+
+  syslog {
+    name        = "syslogtesting-tls"
+    address     = "syslog-tls.example.com"
+    port        = 6514
+    token       = "tf-test"
+    format      = "%%h %%l %%u %%t %%r %%>s %%b"
+    use_tls     = true
+    tls_ca_cert = <<EOF
+    %s
+EOF
+  }
+
+  force_destroy = true
+}`, name, domain, strings.Replace(strings.TrimSpace(testAccSyslogCACert), "\n", "\n    ", -1))
+}
@@ -3,10 +3,13 @@ package fastly
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 	gofastly "github.com/sethvargo/go-fastly"
 )
@@ -72,6 +75,7 @@ func TestResourceFastlyFlattenBackend(t *testing.T) {
 					MaxConn:             uint(200),
 					SSLCheckCert:        true,
 					Weight:              uint(100),
+					HealthCheck:         "test-healthcheck",
 				},
 			},
 			local: []map[string]interface{}{
@@ -87,6 +91,7 @@ func TestResourceFastlyFlattenBackend(t *testing.T) {
 					"max_conn":              200,
 					"ssl_check_cert":        true,
 					"weight":                100,
+					"healthcheck":           "test-healthcheck",
 				},
 			},
 		},
@@ -100,6 +105,277 @@ func TestResourceFastlyFlattenBackend(t *testing.T) {
 	}
 }
 
+func TestResourceFastlyLoggingEndpointResource(t *testing.T) {
+	cases := []struct {
+		key      string
+		endpoint map[string]interface{}
+		hash     int
+	}{
+		{
+			key: "gcslogging",
+			endpoint: map[string]interface{}{
+				"name":               "gcs",
+				"email":              "a@b.c",
+				"bucket_name":        "logs",
+				"secret_key":         "k",
+				"path":               "/fastly",
+				"period":             600,
+				"gzip_level":         9,
+				"format":             "%h",
+				"timestamp_format":   "%Y",
+				"response_condition": "errors",
+			},
+			hash: 2055917031,
+		},
+		{
+			key: "papertrail",
+			endpoint: map[string]interface{}{
+				"name":               "pt",
+				"address":            "logs.papertrailapp.com",
+				"port":               3600,
+				"format":             "%h",
+				"response_condition": "errors",
+			},
+			hash: 3014711504,
+		},
+	}
+
+	r := resourceServiceV1()
+	for _, c := range cases {
+		elem := r.Schema[c.key].Elem.(*schema.Resource)
+		for _, k := range []string{"name", "format", "response_condition"} {
+			if _, ok := elem.Schema[k]; !ok {
+				t.Fatalf("%s: missing the common logging field %q", c.key, k)
+			}
+		}
+
+		// The hashes of endpoints must not change, or every endpoint in
+		// existing state would be replaced
+		d := r.TestResourceData()
+		if err := d.Set(c.key, []interface{}{c.endpoint}); err != nil {
+			t.Fatalf("%s: error setting endpoint: %s", c.key, err)
+		}
+		set := d.Get(c.key).(*schema.Set)
+		if hash := set.F(set.List()[0]); hash != c.hash {
+			t.Fatalf("%s: expected hash %d, got %d", c.key, c.hash, hash)
+		}
+	}
+}
+
+func TestResourceFastlyIsNewerVersion(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"2", "1", true},
+		{"10", "9", true},
+		{"1", "1", false},
+		{"1", "2", false},
+		{"1", "", true},
+		{"", "1", false},
+		{"", "", false},
+	}
+
+	for _, c := range cases {
+		if out := isNewerVersion(c.a, c.b); out != c.expected {
+			t.Fatalf("isNewerVersion(%q, %q): expected %t, got %t", c.a, c.b, c.expected, out)
+		}
+	}
+}
+
+func TestResourceFastlyServiceV1ReadVersion(t *testing.T) {
+	cases := []struct {
+		active, cloned string
+		hold           bool
+		expected       string
+	}{
+		{"1", "1", false, "1"},
+		{"1", "2", false, "2"},
+		{"1", "2", true, "1"},
+		{"2", "1", true, "2"},
+		{"", "1", false, "1"},
+		{"", "1", true, "1"},
+		{"", "", true, ""},
+	}
+
+	for _, c := range cases {
+		if out := serviceV1ReadVersion(c.active, c.cloned, c.hold); out != c.expected {
+			t.Fatalf("serviceV1ReadVersion(%q, %q, %t): expected %q, got %q", c.active, c.cloned, c.hold, c.expected, out)
+		}
+	}
+}
+
+func TestResourceFastlyValidateVersionComment(t *testing.T) {
+	cases := []struct {
+		comment   string
+		expectErr bool
+	}{
+		{"", false},
+		{"applied by terraform", false},
+		{strings.Repeat("a", fastlyVersionCommentMaxLength), false},
+		{strings.Repeat("a", fastlyVersionCommentMaxLength+1), true},
+	}
+
+	for i, c := range cases {
+		_, errs := validateVersionComment(c.comment, "version_comment")
+		if c.expectErr && len(errs) == 0 {
+			t.Fatalf("%d: expected an error, got none", i)
+		}
+		if !c.expectErr && len(errs) > 0 {
+			t.Fatalf("%d: unexpected errors: %s", i, errs)
+		}
+	}
+}
+
+func TestResourceFastlyCheckServiceVersionValidation(t *testing.T) {
+	cases := []struct {
+		body      string
+		valid     bool
+		msg       string
+		expectErr bool
+	}{
+		{
+			body:  `{"status": "ok", "msg": null, "errors": [], "warnings": []}`,
+			valid: true,
+		},
+		{
+			// Warnings fail an otherwise valid version
+			body:  `{"status": "ok", "msg": null, "errors": [], "warnings": ["Backend 'amazon docs' has no healthcheck"]}`,
+			valid: false,
+			msg:   "warning: Backend 'amazon docs' has no healthcheck",
+		},
+		{
+			body:  `{"status": "error", "msg": "Invalid VCL", "errors": [{"message": "Syntax error"}], "warnings": [{"message": "Unused condition"}]}`,
+			valid: false,
+			msg:   "Invalid VCL; error: Syntax error; warning: Unused condition",
+		},
+		{
+			body:      `not json`,
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		valid, msg, err := checkServiceVersionValidation(strings.NewReader(c.body))
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if valid != c.valid {
+			t.Fatalf("%d: expected valid %t, got %t", i, c.valid, valid)
+		}
+		if msg != c.msg {
+			t.Fatalf("%d: expected message %q, got %q", i, c.msg, msg)
+		}
+	}
+}
+
+func TestResourceFastlySummarizeServiceStats(t *testing.T) {
+	cases := []struct {
+		body      string
+		expected  map[string]interface{}
+		expectErr bool
+	}{
+		{
+			body: `{"status": "success", "msg": null, "data": [
+				{"requests": 100, "errors": 2, "hits": 60, "miss": 20},
+				{"requests": 50, "errors": 1, "hits": 30, "miss": 10}
+			]}`,
+			expected: map[string]interface{}{
+				"requests":  "150",
+				"errors":    "3",
+				"hit_ratio": "0.7500",
+			},
+		},
+		{
+			body: `{"status": "success", "msg": null, "data": []}`,
+			expected: map[string]interface{}{
+				"requests":  "0",
+				"errors":    "0",
+				"hit_ratio": "0.0000",
+			},
+		},
+		{
+			body:      `{"status": "error", "msg": "Bad request"}`,
+			expectErr: true,
+		},
+		{
+			body:      `not json`,
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		out, err := summarizeServiceStats(strings.NewReader(c.body))
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if !reflect.DeepEqual(out, c.expected) {
+			t.Fatalf("%d: Error matching:\nexpected: %#v\ngot: %#v", i, c.expected, out)
+		}
+	}
+}
+
+func TestResourceFastlyCheckDomainCNAMEStatuses(t *testing.T) {
+	cases := []struct {
+		body      string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			body: `[
+				[{"name": "www.example.com", "comment": ""}, "global.prod.fastly.net.", true],
+				[{"name": "api.example.com", "comment": ""}, "example.herokudns.com.", false],
+				[{"name": "new.example.com", "comment": ""}, null, false]
+			]`,
+			expected: map[string]string{
+				"www.example.com": "ok",
+				"api.example.com": "incorrect",
+				"new.example.com": "missing",
+			},
+		},
+		{
+			body:     `[]`,
+			expected: map[string]string{},
+		},
+		{
+			body:      `[[{"name": "www.example.com"}, "global.prod.fastly.net."]]`,
+			expectErr: true,
+		},
+		{
+			body:      `{"msg": "Record not found"}`,
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		out, err := checkDomainCNAMEStatuses(strings.NewReader(c.body))
+		if c.expectErr {
+			if err == nil {
+				t.Fatalf("%d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if !reflect.DeepEqual(out, c.expected) {
+			t.Fatalf("%d: Error matching:\nexpected: %#v\ngot: %#v", i, c.expected, out)
+		}
+	}
+}
+
 func TestAccFastlyServiceV1_updateDomain(t *testing.T) {
 	var service gofastly.ServiceDetail
 	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
@@ -196,8 +472,298 @@ func TestAccFastlyServiceV1_basic(t *testing.T) {
 						"fastly_service_v1.foo", "name", name),
 					resource.TestCheckResourceAttr(
 						"fastly_service_v1.foo", "active_version", "1"),
-					resource.TestCheckResourceAttr(
-						"fastly_service_v1.foo", "domain.#", "1"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "domain.#", "1"),
+					testAccCheckFastlyServiceV1GeneratedVCL("fastly_service_v1.foo"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_forceTLS(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_forceTLS(name, domainName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Attributes_forceTLS(&service, true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "force_tls", "true"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "header.#", "0"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1Config_forceTLS(name, domainName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Attributes_forceTLS(&service, false),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "force_tls", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_domainCNAMEStatus(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			// The test domain has no DNS, so it is reported as missing, and the
+			// plan after reading the status is empty
+			resource.TestStep{
+				Config: testAccServiceV1Config(name, domainName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1DomainCNAMEStatus("fastly_service_v1.foo", "missing"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_maintenanceMode(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_maintenanceMode(name, domainName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Attributes_maintenanceMode(&service, true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "maintenance_mode", "true"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "condition.#", "0"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1Config_maintenanceMode(name, domainName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Attributes_maintenanceMode(&service, false),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "maintenance_mode", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_activationToken(t *testing.T) {
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_activationToken(name, domainName1, 3600, "first"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", true),
+				),
+			},
+
+			// Changes are built, but not activated while the token is unchanged
+			resource.TestStep{
+				Config: testAccServiceV1Config_activationToken(name, domainName1, 4800, "first"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", false),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "default_ttl", "4800"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1Config_activationToken(name, domainName1, 4800, "second"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", true),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_versionComment(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_versionComment(name, domainName1, 3600),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1VersionComment(&service, "default_ttl 3600"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "last_version_comment", "default_ttl 3600"),
+				),
+			},
+
+			resource.TestStep{
+				Config: testAccServiceV1Config_versionComment(name, domainName1, 4800),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1VersionComment(&service, "default_ttl 4800"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "last_version_comment", "default_ttl 4800"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_stageBeforeActivate(t *testing.T) {
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_stageBeforeActivate(name, domainName1, 3600, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "staged_version", ""),
+				),
+			},
+
+			// The first phase builds and stages the change
+			resource.TestStep{
+				Config: testAccServiceV1Config_stageBeforeActivate(name, domainName1, 4800, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", false),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "staged_version", "2"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "default_ttl", "4800"),
+				),
+			},
+
+			// The second phase activates the staged version
+			resource.TestStep{
+				Config: testAccServiceV1Config_stageBeforeActivate(name, domainName1, 4800, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "active_version", "2"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "staged_version", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_holdActivation(t *testing.T) {
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName1 := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_holdActivation(name, domainName1, 3600, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "staged_version", ""),
+				),
+			},
+
+			// The change is built but left inactive. The service is read from
+			// the active version, so the change is still planned
+			resource.TestStep{
+				Config: testAccServiceV1Config_holdActivation(name, domainName1, 4800, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", false),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "active_version", "1"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "staged_version", "2"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "default_ttl", "3600"),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+
+			// A later run without hold_activation builds and activates it
+			resource.TestStep{
+				Config: testAccServiceV1Config_holdActivation(name, domainName1, 4800, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckFastlyServiceV1Activated("fastly_service_v1.foo", true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "active_version", "3"),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "default_ttl", "4800"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFastlyServiceV1_collectStats(t *testing.T) {
+	var service gofastly.ServiceDetail
+	name := fmt.Sprintf("tf-test-%s", acctest.RandString(10))
+	domainName := fmt.Sprintf("%s.notadomain.com", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckServiceV1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccServiceV1Config_collectStats(name, domainName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Stats("fastly_service_v1.foo", false),
+				),
+			},
+
+			// Turning collect_stats on populates stats without building a new
+			// version, and the plan after refreshing them is empty
+			resource.TestStep{
+				Config: testAccServiceV1Config_collectStats(name, domainName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckServiceV1Exists("fastly_service_v1.foo", &service),
+					testAccCheckFastlyServiceV1Stats("fastly_service_v1.foo", true),
+					resource.TestCheckResourceAttr(
+						"fastly_service_v1.foo", "active_version", "1"),
 				),
 			},
 		},
@@ -347,6 +913,203 @@ func testAccCheckFastlyServiceV1Attributes_backends(service *gofastly.ServiceDet
 	}
 }
 
+func testAccCheckFastlyServiceV1Attributes_forceTLS(service *gofastly.ServiceDetail, enabled bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		rsList, err := conn.ListRequestSettings(&gofastly.ListRequestSettingsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Request Settings for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		var redirect bool
+		for _, rs := range rsList {
+			if rs.Name == fastlyForceTLSRequestSettingName && rs.ForceSSL {
+				redirect = true
+			}
+		}
+
+		headerList, err := conn.ListHeaders(&gofastly.ListHeadersInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Headers for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		var hsts bool
+		for _, h := range headerList {
+			if h.Name == fastlyForceTLSHeaderName && h.Destination == "http.Strict-Transport-Security" {
+				hsts = true
+			}
+		}
+
+		if redirect != enabled {
+			return fmt.Errorf("force_tls Request Setting mismatch, expected (%t), got (%t)", enabled, redirect)
+		}
+
+		if hsts != enabled {
+			return fmt.Errorf("force_tls HSTS Header mismatch, expected (%t), got (%t)", enabled, hsts)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1DomainCNAMEStatus checks that every domain in
+// state has the given cname_status.
+func testAccCheckFastlyServiceV1DomainCNAMEStatus(n, status string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		var found int
+		for k, v := range rs.Primary.Attributes {
+			if !strings.HasPrefix(k, "domain.") || !strings.HasSuffix(k, ".cname_status") {
+				continue
+			}
+			if v != status {
+				return fmt.Errorf("Expected %s to be %q, got %q", k, status, v)
+			}
+			found++
+		}
+
+		if domains := rs.Primary.Attributes["domain.#"]; strconv.Itoa(found) != domains {
+			return fmt.Errorf("Expected a cname_status for each of %s domains, got %d", domains, found)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1Attributes_maintenanceMode checks that the active
+// version serves the maintenance page for all requests only when enabled.
+func testAccCheckFastlyServiceV1Attributes_maintenanceMode(service *gofastly.ServiceDetail, enabled bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		conditionList, err := conn.ListConditions(&gofastly.ListConditionsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Conditions for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		var condition bool
+		for _, c := range conditionList {
+			if c.Name == fastlyMaintenanceModeName && c.Type == "REQUEST" && c.Statement == "true" {
+				condition = true
+			}
+		}
+
+		roList, err := conn.ListResponseObjects(&gofastly.ListResponseObjectsInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Response Objects for (%s), version (%s): %s", service.Name, service.ActiveVersion.Number, err)
+		}
+
+		var response bool
+		for _, ro := range roList {
+			if ro.Name == fastlyMaintenanceModeName && ro.Status == 503 && ro.RequestCondition == fastlyMaintenanceModeName {
+				response = true
+			}
+		}
+
+		if condition != enabled {
+			return fmt.Errorf("maintenance_mode Condition mismatch, expected (%t), got (%t)", enabled, condition)
+		}
+
+		if response != enabled {
+			return fmt.Errorf("maintenance_mode Response Object mismatch, expected (%t), got (%t)", enabled, response)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1Activated checks whether the most recently built
+// version of the Service is the active one.
+func testAccCheckFastlyServiceV1Activated(n string, activated bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		active := rs.Primary.Attributes["active_version"]
+		cloned := rs.Primary.Attributes["cloned_version"]
+		if (active == cloned) != activated {
+			return fmt.Errorf("Expected activated (%t), got active_version (%s) and cloned_version (%s)", activated, active, cloned)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckFastlyServiceV1VersionComment checks the comment stored on the
+// active version of the Service.
+func testAccCheckFastlyServiceV1VersionComment(service *gofastly.ServiceDetail, comment string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*FastlyClient).conn
+		version, err := conn.GetVersion(&gofastly.GetVersionInput{
+			Service: service.ID,
+			Version: service.ActiveVersion.Number,
+		})
+		if err != nil {
+			return fmt.Errorf("[ERR] Error looking up Version (%s) for (%s): %s", service.ActiveVersion.Number, service.Name, err)
+		}
+
+		if version.Comment != comment {
+			return fmt.Errorf("Version comment mismatch, expected (%s), got (%s)", comment, version.Comment)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckFastlyServiceV1GeneratedVCL(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.Attributes["generated_vcl"] == "" {
+			return fmt.Errorf("Expected generated_vcl to be set")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckFastlyServiceV1Stats(n string, collected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		for _, k := range []string{"requests", "errors", "hit_ratio"} {
+			_, ok := rs.Primary.Attributes["stats."+k]
+			if ok != collected {
+				return fmt.Errorf("Expected stats.%s to be set (%t), got attributes: %#v", k, collected, rs.Primary.Attributes)
+			}
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckServiceV1Destroy(s *terraform.State) error {
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "fastly_service_v1" {
@@ -456,3 +1219,155 @@ resource "fastly_service_v1" "foo" {
   force_destroy = true
 }`, name, backend, backend2)
 }
+
+func testAccServiceV1Config_forceTLS(name, domain string, forceTLS bool) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  force_tls = %t
+
+  force_destroy = true
+}`, name, domain, forceTLS)
+}
+
+func testAccServiceV1Config_maintenanceMode(name, domain string, maintenanceMode bool) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  maintenance_mode = %t
+
+  force_destroy = true
+}`, name, domain, maintenanceMode)
+}
+
+func testAccServiceV1Config_activationToken(name, domain string, ttl int, token string) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  default_ttl      = %d
+  activation_token = "%s"
+
+  force_destroy = true
+}`, name, domain, ttl, token)
+}
+
+func testAccServiceV1Config_versionComment(name, domain string, ttl int) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  default_ttl     = %d
+  version_comment = "${format("default_ttl %%d", %d)}"
+
+  force_destroy = true
+}`, name, domain, ttl, ttl)
+}
+
+func testAccServiceV1Config_stageBeforeActivate(name, domain string, ttl int, activate bool) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  default_ttl           = %d
+  stage_before_activate = true
+  activate              = %t
+
+  force_destroy = true
+}`, name, domain, ttl, activate)
+}
+
+func testAccServiceV1Config_holdActivation(name, domain string, ttl int, hold bool) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  default_ttl     = %d
+  hold_activation = %t
+
+  force_destroy = true
+}`, name, domain, ttl, hold)
+}
+
+func testAccServiceV1Config_collectStats(name, domain string, collectStats bool) string {
+	return fmt.Sprintf(`
+resource "fastly_service_v1" "foo" {
+  name = "%s"
+
+  domain {
+    name    = "%s"
+    comment = "tf-testing-domain"
+  }
+
+  backend {
+    address = "aws.amazon.com"
+    name    = "amazon docs"
+  }
+
+  collect_stats = %t
+
+  force_destroy = true
+}`, name, domain, collectStats)
+}